@@ -0,0 +1,229 @@
+package req
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nordborn/go-errow"
+)
+
+// Auth authenticates the request of each attempt.
+// Headers set by Auth are removed if the request
+// is redirected to a different host
+type Auth interface {
+	Authenticate(request *http.Request) error
+}
+
+// RefreshableAuth is Auth which can drop credentials rejected by server.
+// Send retries request once with refreshed credentials on 401 response
+// if Refresh returns true: the retry is a separate attempt
+// (RetryReasonAuth) which doesn't consume Attempts
+type RefreshableAuth interface {
+	Auth
+	// Refresh drops credentials used by request
+	// or updates them from 401 response (respRaw)
+	Refresh(request *http.Request, respRaw *http.Response) bool
+}
+
+// AuthFunc adapts func to Auth
+type AuthFunc func(request *http.Request) error
+
+// Authenticate implements Auth
+func (f AuthFunc) Authenticate(request *http.Request) error {
+	return f(request)
+}
+
+// BasicAuth sets HTTP Basic Authorization header
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// Authenticate implements Auth
+func (a BasicAuth) Authenticate(request *http.Request) error {
+	request.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// BearerAuth sets "Authorization: Bearer <token>" header
+// with the token from Token (see StaticToken and CachedToken)
+type BearerAuth struct {
+	Token TokenSource
+}
+
+// Authenticate implements Auth
+func (a BearerAuth) Authenticate(request *http.Request) error {
+	token, err := a.Token.Token(request.Context())
+	if err != nil {
+		return errow.Wrap(err, "can't get token")
+	}
+	request.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Refresh implements RefreshableAuth: invalidates the token used by request
+// if Token has Invalidate method (like CachedToken)
+func (a BearerAuth) Refresh(request *http.Request, _ *http.Response) bool {
+	if t, ok := a.Token.(interface{ Invalidate(token string) }); ok {
+		t.Invalidate(strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer "))
+		return true
+	}
+	return false
+}
+
+// APIKeyAuth sets API key header Name
+// or query param Name if InQuery.
+// Add query param Name to Redactor.Params if it's not there
+type APIKeyAuth struct {
+	Name    string
+	Key     string
+	InQuery bool
+}
+
+// Authenticate implements Auth
+func (a APIKeyAuth) Authenticate(request *http.Request) error {
+	if !a.InQuery {
+		request.Header.Set(a.Name, a.Key)
+		return nil
+	}
+	// keep the order and encoding style of Params
+	request.URL.RawQuery = appendQuery(request.URL.RawQuery, a.Name, a.Key)
+	return nil
+}
+
+// TokenSource provides tokens for BearerAuth
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is TokenSource of the same token
+type StaticToken string
+
+// Token implements TokenSource
+func (t StaticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+// TokenFunc fetches a new token and its expiry time
+// (zero time means that the token never expires)
+type TokenFunc func(ctx context.Context) (token string, expiry time.Time, err error)
+
+// CachedToken is TokenSource which fetches the token lazily
+// on the first use and when it's expired. It's safe for concurrent use
+type CachedToken struct {
+	// Fetch fetches new tokens
+	Fetch TokenFunc
+
+	// Early: the token is refreshed this duration before its expiry
+	Early time.Duration
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewCachedToken generates CachedToken refreshing tokens
+// 10 seconds before expiry
+func NewCachedToken(fetch TokenFunc) *CachedToken {
+	return &CachedToken{Fetch: fetch, Early: 10 * time.Second}
+}
+
+// Token implements TokenSource
+func (c *CachedToken) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && (c.expiry.IsZero() || time.Now().Add(c.Early).Before(c.expiry)) {
+		return c.token, nil
+	}
+	token, expiry, err := c.Fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.token, c.expiry = token, expiry
+	return token, nil
+}
+
+// Invalidate drops the cached token if it's token (rejected one,
+// but not already refreshed), so the next call of Token fetches
+// a new one (BearerAuth calls it on 401 response)
+func (c *CachedToken) Invalidate(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == token {
+		c.token = ""
+	}
+}
+
+// requestBody returns copy of in-memory request body for signing
+func requestBody(request *http.Request) ([]byte, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, nil
+	}
+	if request.GetBody == nil {
+		return nil, errow.New("streamed body can't be signed")
+	}
+	body, err := request.GetBody()
+	if err != nil {
+		return nil, errow.Wrap(err, "can't get body")
+	}
+	defer body.Close()
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, errow.Wrap(err, "can't read body")
+	}
+	return b, nil
+}
+
+// authenticate applies Auth to reqRaw and remembers headers set by it
+func (r *Req) authenticate() error {
+	r.authHeaders = nil
+	if r.Auth == nil {
+		return nil
+	}
+	before := r.reqRaw.Header.Clone()
+	if err := r.Auth.Authenticate(r.reqRaw); err != nil {
+		return errow.Wrap(err, "auth failed")
+	}
+	for k, vs := range r.reqRaw.Header {
+		if strings.Join(vs, "\n") != strings.Join(before[k], "\n") {
+			r.authHeaders = append(r.authHeaders, k)
+		}
+	}
+	return nil
+}
+
+// client returns a copy of Client with Timeout and proxy of Req
+// which removes auth headers on redirects to a different host.
+// Client itself isn't modified as it may be shared by requests
+func (r *Req) client() *http.Client {
+	c := *r.Client
+	c.Timeout = r.Timeout
+	if r.proxyTransport != nil {
+		c.Transport = r.proxyTransport
+	}
+	if r.Auth == nil {
+		return &c
+	}
+	checkRedirect := r.Client.CheckRedirect
+	authHeaders := r.authHeaders
+	c.CheckRedirect = func(request *http.Request, via []*http.Request) error {
+		if !strings.EqualFold(request.URL.Host, via[0].URL.Host) {
+			request.Header.Del("Authorization")
+			for _, k := range authHeaders {
+				request.Header.Del(k)
+			}
+		}
+		if checkRedirect != nil {
+			return checkRedirect(request, via)
+		}
+		if len(via) >= 10 {
+			return errow.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &c
+}
@@ -0,0 +1,118 @@
+package req
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReqSend_Auth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("Authorization"), "|", r.Header.Get("X-Api-Key"), "|", r.URL.RawQuery)
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		auth Auth
+		want string
+	}{
+		{BasicAuth{"user", "pass"}, "Basic dXNlcjpwYXNz||b=2&a=1&c=x,y"},
+		{BearerAuth{StaticToken("abc")}, "Bearer abc||b=2&a=1&c=x,y"},
+		{APIKeyAuth{Name: "X-Api-Key", Key: "k1"}, "|k1|b=2&a=1&c=x,y"},
+		{APIKeyAuth{Name: "api_key", Key: "k/2", InQuery: true}, "||b=2&a=1&c=x,y&api_key=k%2F2"},
+	}
+	for _, c := range cases {
+		resp, err := New(srv.URL).WithParams(Vals{{"b", 2}, {"a", 1}, {"c", []string{"x", "y"}}}).
+			WithParamsStyle(StyleComma).WithAuth(c.auth).Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text() != c.want {
+			t.Fatal("Unexpected response:", resp.Text(), "expected:", c.want)
+		}
+	}
+}
+
+func TestCachedToken(t *testing.T) {
+	fetched := 0
+	expiry := time.Now().Add(time.Hour)
+	ct := NewCachedToken(func(ctx context.Context) (string, time.Time, error) {
+		fetched++
+		return fmt.Sprint("t", fetched), expiry, nil
+	})
+	ctx := context.Background()
+
+	token, _ := ct.Token(ctx)
+	token2, _ := ct.Token(ctx)
+	if token != "t1" || token2 != "t1" {
+		t.Fatal("Unexpected tokens:", token, token2)
+	}
+	// expires soon
+	expiry = time.Now().Add(time.Second)
+	// already refreshed token isn't dropped
+	ct.Invalidate("t0")
+	if token, _ = ct.Token(ctx); token != "t1" {
+		t.Fatal("Unexpected token:", token)
+	}
+	ct.Invalidate("t1")
+	token, _ = ct.Token(ctx)
+	token2, _ = ct.Token(ctx)
+	if token != "t2" || token2 != "t3" {
+		t.Fatal("Unexpected refreshed tokens:", token, token2)
+	}
+
+	// BearerAuth drops only the rejected token
+	expiry = time.Now().Add(time.Hour)
+	auth := BearerAuth{Token: ct}
+	ct.Token(ctx)
+	auth.Refresh(&http.Request{Header: http.Header{"Authorization": {"Bearer t3"}}}, nil)
+	if token, _ = ct.Token(ctx); token != "t4" {
+		t.Fatal("Unexpected token:", token)
+	}
+	auth.Refresh(&http.Request{Header: http.Header{"Authorization": {"Bearer t4"}}}, nil)
+	if token, _ = ct.Token(ctx); token != "t5" {
+		t.Fatal("Unexpected token:", token)
+	}
+}
+
+func TestReqSend_AuthRedirect(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "other:", r.Header.Get("Authorization"), r.Header.Get("X-Token"))
+	}))
+	defer other.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/same":
+			http.Redirect(w, r, "/final", http.StatusFound)
+		case "/other":
+			http.Redirect(w, r, other.URL, http.StatusFound)
+		default:
+			fmt.Fprint(w, "same:", r.Header.Get("Authorization"), r.Header.Get("X-Token"))
+		}
+	}))
+	defer srv.Close()
+
+	auth := AuthFunc(func(request *http.Request) error {
+		request.Header.Set("Authorization", "Bearer abc")
+		request.Header.Set("X-Token", "xyz")
+		return nil
+	})
+	resp, err := New(srv.URL).WithPath("/same").WithAuth(auth).Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "same:Bearer abcxyz" {
+		t.Fatal("Unexpected response:", resp.Text())
+	}
+
+	resp, err = New(srv.URL).WithPath("/other").WithAuth(auth).Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "other:" {
+		t.Fatal("Unexpected response:", resp.Text())
+	}
+}
@@ -0,0 +1,101 @@
+package req
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/nordborn/go-errow"
+)
+
+// Supported values of Req.Compress (they are sent as Content-Encoding)
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+	EncodingZstd    = "zstd"
+)
+
+// sizedReader is implemented by in-memory readers
+// (*strings.Reader, *bytes.Reader, *bytes.Buffer)
+type sizedReader interface {
+	io.Reader
+	Len() int
+}
+
+// newEncoder returns compressing writer for the encoding.
+// Note, that HTTP "deflate" is zlib format (RFC 9110), not raw deflate
+func newEncoder(encoding string, w io.Writer) (io.WriteCloser, error) {
+	switch encoding {
+	case EncodingGzip:
+		return gzip.NewWriter(w), nil
+	case EncodingDeflate:
+		return zlib.NewWriter(w), nil
+	case EncodingZstd:
+		return zstd.NewWriter(w)
+	}
+	return nil, errow.New("unsupported compression: ", encoding)
+}
+
+// compressBody compresses body with the encoding
+// if body size is at least minBytes (and body is not empty).
+// In-memory bodies are compressed at once to keep Content-Length,
+// streamed bodies are compressed on the fly via io.Pipe.
+// Returns reader to send and whether it was compressed
+func compressBody(encoding string, minBytes int, body io.Reader) (io.Reader, bool, error) {
+	if minBytes < 1 {
+		minBytes = 1
+	}
+
+	if sized, ok := body.(sizedReader); ok {
+		if sized.Len() < minBytes {
+			return body, false, nil
+		}
+		var buf bytes.Buffer
+		enc, err := newEncoder(encoding, &buf)
+		if err != nil {
+			return nil, false, err
+		}
+		if _, err = io.Copy(enc, sized); err != nil {
+			return nil, false, errow.Wrap(err)
+		}
+		if err = enc.Close(); err != nil {
+			return nil, false, errow.Wrap(err)
+		}
+		return &buf, true, nil
+	}
+
+	// streamed body: peek minBytes to decide,
+	// the buffer grows as data arrives
+	var head bytes.Buffer
+	if _, err := head.ReadFrom(io.LimitReader(body, int64(minBytes))); err != nil {
+		return nil, false, errow.Wrap(err)
+	}
+	if head.Len() < minBytes {
+		if c, ok := body.(io.Closer); ok {
+			c.Close()
+		}
+		return &head, false, nil
+	}
+
+	pr, pw := io.Pipe()
+	enc, err := newEncoder(encoding, pw)
+	if err != nil {
+		return nil, false, err
+	}
+	go func() {
+		_, err := io.Copy(enc, io.MultiReader(&head, body))
+		// always close the encoder to release its resources
+		if closeErr := enc.Close(); err == nil {
+			err = closeErr
+		}
+		if c, ok := body.(io.Closer); ok {
+			c.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	// *io.PipeReader is io.ReadCloser, so the transport closes it
+	// and stops the goroutine even if the body wasn't read till the end
+	return pr, true, nil
+}
@@ -0,0 +1,159 @@
+package req
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// newEchoBodyServer responds with decoded request body
+// and Content-Encoding in X-Encoding header
+func newEchoBodyServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		var err error
+		switch r.Header.Get("Content-Encoding") {
+		case EncodingGzip:
+			body, err = gzip.NewReader(r.Body)
+		case EncodingDeflate:
+			body, err = zlib.NewReader(r.Body)
+		case EncodingZstd:
+			body, err = zstd.NewReader(r.Body)
+		}
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		w.Header().Set("X-Encoding", r.Header.Get("Content-Encoding"))
+		io.Copy(w, body)
+	}))
+}
+
+func TestReqPost_Compress(t *testing.T) {
+	srv := newEchoBodyServer(t)
+	defer srv.Close()
+
+	body := strings.Repeat(`{"n":"v"}`, 100)
+	for _, enc := range []string{EncodingGzip, EncodingDeflate, EncodingZstd} {
+		resp, err := New(srv.URL).WithBody(body).WithCompress(enc, 0).Post()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text() != body {
+			t.Fatal("Unexpected response:", enc, resp.Text())
+		}
+		if resp.RespRaw.Header.Get("X-Encoding") != enc {
+			t.Fatal("Unexpected encoding:", resp.RespRaw.Header.Get("X-Encoding"))
+		}
+	}
+}
+
+func TestReqPost_CompressMinBytes(t *testing.T) {
+	srv := newEchoBodyServer(t)
+	defer srv.Close()
+
+	r := New(srv.URL).WithForm(Vals{{"n1", "v1"}}).WithCompress(EncodingGzip, 1024)
+	resp, err := r.Post()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.RespRaw.Header.Get("X-Encoding") != "" || resp.Text() != "n1=v1" {
+		t.Fatal("Unexpected compression of small body:", resp.Text())
+	}
+}
+
+func TestReqPost_CompressBodyStream(t *testing.T) {
+	srv := newEchoBodyServer(t)
+	defer srv.Close()
+
+	body := strings.Repeat("line\n", 1000)
+	stream := func() (io.Reader, error) {
+		return io.MultiReader(strings.NewReader(body)), nil
+	}
+	r := New(srv.URL).WithBodyStream(stream).WithCompress(EncodingZstd, 100)
+	resp, err := r.Post()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.RespRaw.Header.Get("X-Encoding") != EncodingZstd || resp.Text() != body {
+		t.Fatal("Unexpected response:", resp.Text())
+	}
+
+	// short stream is not compressed
+	body = "short"
+	resp, err = r.Post()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.RespRaw.Header.Get("X-Encoding") != "" || resp.Text() != body {
+		t.Fatal("Unexpected response:", resp.Text())
+	}
+}
+
+func Test_compressBody_Unsupported(t *testing.T) {
+	_, _, err := compressBody("br", 0, strings.NewReader("body"))
+	if err == nil {
+		t.Fatal("Expected err, but got nil")
+	}
+}
+
+// closeCounter counts closed bodies
+type closeCounter struct {
+	io.Reader
+	closed *atomic.Int32
+}
+
+func (c closeCounter) Close() error {
+	c.closed.Add(1)
+	return nil
+}
+
+func Test_compressBody_StreamErr(t *testing.T) {
+	closed := &atomic.Int32{}
+	body := closeCounter{io.MultiReader(strings.NewReader(strings.Repeat("x", 200)),
+		iotest.ErrReader(errors.New("broken stream"))), closed}
+	pr, compressed, err := compressBody(EncodingZstd, 100, body)
+	if err != nil || !compressed {
+		t.Fatal("Unexpected result:", compressed, err)
+	}
+	if _, err = io.ReadAll(pr); err == nil || err.Error() != "broken stream" {
+		t.Fatal("Expected stream err, but got:", err)
+	}
+	if closed.Load() != 1 {
+		t.Fatal("Stream is not closed")
+	}
+}
+
+func TestReqPost_CompressBodyStreamRebuild(t *testing.T) {
+	srv := newEchoBodyServer(t)
+	defer srv.Close()
+
+	opened, closed := 0, &atomic.Int32{}
+	stream := func() (io.Reader, error) {
+		opened++
+		return closeCounter{strings.NewReader(strings.Repeat("line\n", 1000)), closed}, nil
+	}
+	// the body built before the rebuild is released
+	_, err := New(srv.URL).WithBodyStream(stream).WithCompress(EncodingZstd, 100).
+		WithInterceptors(MiddlewareFunc(func() {})).Post()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for int(closed.Load()) != opened && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if opened != 2 || closed.Load() != 2 {
+		t.Fatal("Unexpected opened and closed streams:", opened, closed.Load())
+	}
+}
@@ -0,0 +1,191 @@
+package req
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Debug dumps outgoing requests and incoming responses of each attempt
+// (similar to httputil.DumpRequestOut and httputil.DumpResponse)
+// with the retry reason to Writer.
+// Dumps are redacted with Req.Redactor.
+// Request headers are dumped as they were written to the wire
+type Debug struct {
+	// Writer receives dumps. Default is os.Stderr
+	Writer io.Writer
+
+	// Bodies: dump request and response bodies
+	Bodies bool
+
+	// MaxBodyBytes: bodies are truncated to this size (0 - no limit)
+	MaxBodyBytes int
+
+	mu sync.Mutex
+}
+
+// NewDebug generates Debug writing to w with bodies truncated to 4KB
+func NewDebug(w io.Writer) *Debug {
+	return &Debug{
+		Writer:       w,
+		Bodies:       true,
+		MaxBodyBytes: 4096,
+	}
+}
+
+// debugAttempt collects wire-level data of single attempt
+type debugAttempt struct {
+	headers [][2]string
+	body    *captureBody
+}
+
+// start prepares request to collect data for the dump
+func (d *Debug) start(request *http.Request) (*http.Request, *debugAttempt) {
+	da := &debugAttempt{}
+	trace := &httptrace.ClientTrace{
+		WroteHeaderField: func(key string, values []string) {
+			for _, v := range values {
+				da.headers = append(da.headers, [2]string{key, v})
+			}
+		},
+	}
+	request = request.WithContext(httptrace.WithClientTrace(request.Context(), trace))
+
+	if d.Bodies && request.Body != nil && request.Body != http.NoBody {
+		// capture up to MaxBodyBytes, the dump marks the body as truncated
+		// (truncated JSON is redacted by field names)
+		limit := int64(d.MaxBodyBytes)
+		if limit <= 0 {
+			limit = -1
+		}
+		da.body = &captureBody{ReadCloser: request.Body, limit: limit}
+		request.Body = da.body
+	}
+	return request, da
+}
+
+// dump writes the attempt dump to Writer
+func (d *Debug) dump(rd *Redactor, request *http.Request, da *debugAttempt,
+	info AttemptInfo, respRaw *http.Response, content []byte, retry bool) {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, ">>> attempt #%v\n", info.Num)
+	fmt.Fprintf(&b, "%v %v %v\n", request.Method, rd.URL(request.URL.RequestURI()), request.Proto)
+	for _, h := range da.headers {
+		if rd.isHeader(h[0]) {
+			h[1] = rd.mask()
+		}
+		fmt.Fprintf(&b, "%v: %v\n", h[0], rd.Text(h[1]))
+	}
+	if da.body != nil {
+		body, total := da.body.captured()
+		b.WriteString("\n")
+		d.writeBody(&b, rd, request.Header, body, total)
+	}
+
+	fmt.Fprintf(&b, "<<< attempt #%v (%v", info.Num, info.Duration)
+	if info.Timing.RemoteAddr != "" {
+		fmt.Fprintf(&b, ", %v", info.Timing.RemoteAddr)
+	}
+	if info.Timing.ConnReused {
+		b.WriteString(", reused connection")
+	}
+	b.WriteString(")\n")
+	if respRaw != nil {
+		fmt.Fprintf(&b, "%v %v\n", respRaw.Proto, respRaw.Status)
+		header := rd.Header(respRaw.Header)
+		keys := make([]string, 0, len(header))
+		for k := range header {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			for _, v := range header[k] {
+				fmt.Fprintf(&b, "%v: %v\n", k, v)
+			}
+		}
+		if d.Bodies {
+			b.WriteString("\n")
+			d.writeBody(&b, rd, respRaw.Header, content, int64(len(content)))
+		}
+	}
+
+	switch {
+	case info.Reason == "":
+		fmt.Fprintf(&b, "=== attempt #%v succeeded\n\n", info.Num)
+	case retry:
+		fmt.Fprintf(&b, "=== attempt #%v failed, retry: %v\n\n", info.Num, info.Reason)
+	default:
+		fmt.Fprintf(&b, "=== attempt #%v failed: %v\n\n", info.Num, info.Reason)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	w := d.Writer
+	if w == nil {
+		w = os.Stderr
+	}
+	w.Write(b.Bytes())
+}
+
+// writeBody writes redacted and truncated body of total size
+func (d *Debug) writeBody(b *bytes.Buffer, rd *Redactor, header http.Header, body []byte, total int64) {
+	if enc := header.Get("Content-Encoding"); enc != "" && enc != "identity" {
+		fmt.Fprintf(b, "[%v bytes of %v encoded body]\n", total, enc)
+		return
+	}
+	truncated := total > int64(len(body))
+	body = rd.Body(header.Get("Content-Type"), body)
+	if d.MaxBodyBytes > 0 && len(body) > d.MaxBodyBytes {
+		body = body[:d.MaxBodyBytes]
+		truncated = true
+	}
+	b.Write(body)
+	if truncated {
+		fmt.Fprintf(b, "\n[truncated, %v bytes total]", total)
+	}
+	if !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+		b.WriteString("\n")
+	}
+}
+
+// captureBody keeps up to limit bytes of the body read by the transport
+// (limit < 0 means no limit) and counts total size
+type captureBody struct {
+	io.ReadCloser
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	limit int64
+	n     int64
+}
+
+// captured returns copy of captured bytes and total size.
+// The transport can still write the body after the response arrived
+func (c *captureBody) captured() ([]byte, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return bytes.Clone(c.buf.Bytes()), c.n
+}
+
+// Read implements io.Reader
+func (c *captureBody) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n > 0 {
+		keep := int64(n)
+		if c.limit >= 0 && int64(c.buf.Len())+keep > c.limit {
+			keep = c.limit - int64(c.buf.Len())
+		}
+		if keep > 0 {
+			c.buf.Write(p[:keep])
+		}
+		c.n += int64(n)
+	}
+	return n, err
+}
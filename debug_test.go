@@ -0,0 +1,105 @@
+package req
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReqSend_Debug(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		w.Write([]byte(`{"access_token":"secret-token","data":"` + strings.Repeat("x", 100) + `"}`))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	debug := NewDebug(&buf)
+	debug.MaxBodyBytes = 50
+	r := New(srv.URL).WithDebug(debug).WithAttempts(2).WithRetryOnTextMarkers(nil).
+		WithParams(Vals{{"token", "secret-param"}}).
+		WithHeaders(Vals{{"Authorization", "Bearer secret-header"}, HeaderAppJSON}).
+		WithBody(`{"password":"secret-body","n":1}`)
+	if _, err := r.Post(); err != nil {
+		t.Fatal(err)
+	}
+
+	dump := buf.String()
+	for _, s := range []string{
+		">>> attempt #1\nPOST /?token=[REDACTED] HTTP/1.1\n",
+		"Authorization: [REDACTED]\n",
+		"Content-Type: application/json\n",
+		`{"password":"[REDACTED]","n":1}`,
+		"<<< attempt #1 (",
+		"HTTP/1.1 502 Bad Gateway\n",
+		`{"access_token":"[REDACTED]","data":"xxxx`,
+		"[truncated, 141 bytes total]",
+		"=== attempt #1 failed, retry: finally got unwanted status code '502'",
+		"HTTP/1.1 200 OK\n",
+		"=== attempt #2 succeeded\n",
+	} {
+		if !strings.Contains(dump, s) {
+			t.Fatalf("Not found %q in dump:\n%v", s, dump)
+		}
+	}
+	if strings.Contains(dump, "secret-") {
+		t.Fatal("Secret is not redacted:", dump)
+	}
+}
+
+func TestReqSend_DebugNoBodies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("response body"))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	_, err := New(srv.URL).WithDebug(&Debug{Writer: &buf}).WithBody("request body").Post()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "body") || !strings.Contains(buf.String(), "Content-Length: 12\n") {
+		t.Fatal("Unexpected dump:", buf.String())
+	}
+}
+
+func TestReqSend_DebugLargeBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	debug := NewDebug(&buf)
+	debug.MaxBodyBytes = 30
+	body := `{"password":"secret-body","data":"` + strings.Repeat("x", 1000) + `"}`
+	_, err := New(srv.URL).WithDebug(debug).WithHeaders(Vals{HeaderAppJSON}).WithBody(body).Post()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump := buf.String()
+	if !strings.Contains(dump, `{"password":"[REDACTED]","dat`) ||
+		!strings.Contains(dump, "[truncated, 1036 bytes total]") || strings.Contains(dump, "secret-") {
+		t.Fatal("Unexpected dump:", dump)
+	}
+	if n := debugCaptured(t, debug, body); n != 30 {
+		t.Fatal("Unexpected captured size:", n)
+	}
+}
+
+// debugCaptured returns number of bytes of body captured by debug
+func debugCaptured(t *testing.T, debug *Debug, body string) int {
+	request, _ := http.NewRequest("POST", "http://example.com", strings.NewReader(body))
+	request, da := debug.start(request)
+	if _, err := io.ReadAll(request.Body); err != nil {
+		t.Fatal(err)
+	}
+	captured, _ := da.body.captured()
+	return len(captured)
+}
@@ -0,0 +1,258 @@
+package req
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/nordborn/go-errow"
+)
+
+// DigestAuth is HTTP Digest authentication (RFC 7616) with
+// MD5, MD5-sess, SHA-256 and SHA-256-sess algorithms and
+// qop=auth or auth-int.
+// The first request gets 401 challenge and Send retries it
+// with credentials, next requests reuse the challenge
+// (with incremented nonce count) without extra round-trip.
+// Share it between requests (Session.Auth) to reuse the challenge.
+// It's safe for concurrent use
+type DigestAuth struct {
+	Username string
+	Password string
+
+	// AuthInt: use qop=auth-int (with body hash) if server supports it.
+	// Requests with streamed bodies fall back to qop=auth
+	AuthInt bool
+
+	mu        sync.Mutex
+	challenge map[string]string
+	nc        int
+}
+
+// NewDigestAuth generates DigestAuth
+func NewDigestAuth(username, password string) *DigestAuth {
+	return &DigestAuth{Username: username, Password: password}
+}
+
+// Authenticate implements Auth: sets Authorization header
+// if the challenge is known
+func (d *DigestAuth) Authenticate(request *http.Request) error {
+	d.mu.Lock()
+	if d.challenge == nil {
+		d.mu.Unlock()
+		return nil
+	}
+	d.nc++
+	nc := d.nc
+	c := d.challenge
+	d.mu.Unlock()
+
+	algorithm := c["algorithm"]
+	if algorithm == "" {
+		algorithm = "MD5"
+	}
+	newHash := digestHash(algorithm)
+	if newHash == nil {
+		return errow.Newf("unsupported digest algorithm '%v'", algorithm)
+	}
+	h := func(parts ...string) string {
+		hh := newHash()
+		io.WriteString(hh, strings.Join(parts, ":"))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+
+	qop, err := d.qop(c["qop"], request)
+	if err != nil {
+		return err
+	}
+	uri := request.URL.RequestURI()
+	cnonce := randomString(12)
+	ncValue := fmt.Sprintf("%08x", nc)
+
+	ha1 := h(d.Username, c["realm"], d.Password)
+	if strings.HasSuffix(strings.ToLower(algorithm), "-sess") {
+		ha1 = h(ha1, c["nonce"], cnonce)
+	}
+	ha2 := h(request.Method, uri)
+	if qop == "auth-int" {
+		body, err := requestBody(request)
+		if err != nil {
+			return err
+		}
+		ha2 = h(request.Method, uri, h(string(body)))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `Digest username=%v, realm=%v, nonce=%v, uri=%v, algorithm=%v`,
+		quoteParam(d.Username), quoteParam(c["realm"]), quoteParam(c["nonce"]), quoteParam(uri), algorithm)
+	if qop == "" {
+		// RFC 2069 compatibility
+		fmt.Fprintf(&b, `, response="%v"`, h(ha1, c["nonce"], ha2))
+	} else {
+		fmt.Fprintf(&b, `, response="%v", qop=%v, nc=%v, cnonce="%v"`,
+			h(ha1, c["nonce"], ncValue, cnonce, qop, ha2), qop, ncValue, cnonce)
+	}
+	if opaque, ok := c["opaque"]; ok {
+		fmt.Fprintf(&b, `, opaque=%v`, quoteParam(opaque))
+	}
+	request.Header.Set("Authorization", b.String())
+	return nil
+}
+
+// qop chooses qop from offered ones ("" if server doesn't support qop)
+func (d *DigestAuth) qop(offered string, request *http.Request) (string, error) {
+	if offered == "" {
+		return "", nil
+	}
+	auth, authInt := false, false
+	for _, q := range strings.Split(offered, ",") {
+		switch strings.TrimSpace(q) {
+		case "auth":
+			auth = true
+		case "auth-int":
+			authInt = true
+		}
+	}
+	bodyAvailable := request.Body == nil || request.Body == http.NoBody || request.GetBody != nil
+	switch {
+	case authInt && (d.AuthInt || !auth) && bodyAvailable:
+		return "auth-int", nil
+	case auth:
+		return "auth", nil
+	case authInt:
+		return "", errow.New("qop=auth-int requires in-memory body")
+	}
+	return "", errow.Newf("unsupported digest qop '%v'", offered)
+}
+
+// Refresh implements RefreshableAuth: stores the challenge of 401 response.
+// It returns false if the nonce of the challenge was already used
+// by request (bad credentials) unless the nonce is stale
+func (d *DigestAuth) Refresh(request *http.Request, respRaw *http.Response) bool {
+	c := digestChallenge(respRaw.Header.Values("WWW-Authenticate"))
+	if c == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// the same nonce was rejected
+	for _, used := range parseChallenges(request.Header.Values("Authorization")) {
+		if used.params["nonce"] == c["nonce"] && !strings.EqualFold(c["stale"], "true") {
+			return false
+		}
+	}
+	d.challenge = c
+	d.nc = 0
+	return true
+}
+
+// digestChallenge returns params of the strongest Digest challenge
+// among WWW-Authenticate header values (nil if there are no supported ones)
+func digestChallenge(values []string) map[string]string {
+	var best map[string]string
+	for _, c := range parseChallenges(values) {
+		if !strings.EqualFold(c.scheme, "Digest") || c.params["nonce"] == "" {
+			continue
+		}
+		algorithm := c.params["algorithm"]
+		if digestHash(algorithm) == nil && algorithm != "" {
+			continue
+		}
+		if best == nil || strings.HasPrefix(strings.ToUpper(algorithm), "SHA-256") {
+			best = c.params
+		}
+	}
+	return best
+}
+
+// digestHash returns hash of algorithm (nil if unsupported)
+func digestHash(algorithm string) func() hash.Hash {
+	switch strings.ToUpper(algorithm) {
+	case "", "MD5", "MD5-SESS":
+		return md5.New
+	case "SHA-256", "SHA-256-SESS":
+		return sha256.New
+	}
+	return nil
+}
+
+// challenge is auth scheme with params of WWW-Authenticate header
+type challenge struct {
+	scheme string
+	params map[string]string
+}
+
+// parseChallenges parses WWW-Authenticate (or Authorization)
+// header values (RFC 9110 11.6.1), param names are lowercased
+func parseChallenges(values []string) []challenge {
+	var challenges []challenge
+	for _, s := range values {
+		for s = strings.TrimSpace(s); s != ""; s = strings.TrimLeft(s, " \t,") {
+			var token string
+			token, s = cutToken(s)
+			if token == "" {
+				// skip bad char
+				s = s[1:]
+				continue
+			}
+			s = strings.TrimLeft(s, " \t")
+			if rest := strings.TrimLeft(s, "="); rest != s &&
+				(strings.TrimLeft(rest, " \t") == "" || strings.HasPrefix(rest, ",")) {
+				// token68 (like base64 credentials) isn't used here
+				s = rest
+				continue
+			}
+			if strings.HasPrefix(s, "=") && len(challenges) > 0 {
+				// auth-param of the current challenge
+				var value string
+				value, s = cutParamValue(strings.TrimLeft(s[1:], " \t"))
+				challenges[len(challenges)-1].params[strings.ToLower(token)] = value
+				continue
+			}
+			challenges = append(challenges, challenge{scheme: token, params: map[string]string{}})
+		}
+	}
+	return challenges
+}
+
+// cutToken cuts token chars from the beginning of s
+func cutToken(s string) (string, string) {
+	i := strings.IndexAny(s, " \t,=\"")
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i:]
+}
+
+// quoteParam returns quoted-string of s
+func quoteParam(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// cutParamValue cuts token or quoted string from the beginning of s
+func cutParamValue(s string) (string, string) {
+	if !strings.HasPrefix(s, `"`) {
+		return cutToken(s)
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), s[i+1:]
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), ""
+}
@@ -0,0 +1,119 @@
+package req
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newDigestServer returns server checking Digest credentials user:pass
+// with algorithm and qop, and records nonce counts of accepted requests
+func newDigestServer(t *testing.T, algorithm, qop string, ncs *[]string) *httptest.Server {
+	newHash := md5.New
+	if strings.HasPrefix(algorithm, "SHA-256") {
+		newHash = sha256.New
+	}
+	h := func(s string) string {
+		var hh hash.Hash = newHash()
+		io.WriteString(hh, s)
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+	const nonce, realm = "n1", "test@example.com"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cs := parseChallenges(r.Header.Values("Authorization"))
+		if len(cs) == 1 && cs[0].scheme == "Digest" && cs[0].params["nonce"] == nonce {
+			p := cs[0].params
+			ha1 := h("user:" + realm + ":pass")
+			if strings.HasSuffix(algorithm, "-sess") {
+				ha1 = h(ha1 + ":" + nonce + ":" + p["cnonce"])
+			}
+			ha2 := h(r.Method + ":" + r.URL.RequestURI())
+			if p["qop"] == "auth-int" {
+				body, _ := io.ReadAll(r.Body)
+				ha2 = h(r.Method + ":" + r.URL.RequestURI() + ":" + h(string(body)))
+			}
+			want := h(ha1 + ":" + nonce + ":" + p["nc"] + ":" + p["cnonce"] + ":" + p["qop"] + ":" + ha2)
+			if p["response"] == want && p["uri"] == r.URL.RequestURI() && p["opaque"] == "op" &&
+				p["qop"] == qop {
+				*ncs = append(*ncs, p["nc"])
+				fmt.Fprint(w, "ok")
+				return
+			}
+		}
+		w.Header().Add("WWW-Authenticate", `Basic realm="basic"`)
+		w.Header().Add("WWW-Authenticate", fmt.Sprintf(
+			`Digest realm=%q, qop="auth, auth-int", algorithm=%v, nonce=%q, opaque="op"`,
+			realm, algorithm, nonce))
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestReqSend_DigestAuth(t *testing.T) {
+	for _, algorithm := range []string{"MD5", "SHA-256", "SHA-256-sess"} {
+		var ncs []string
+		srv := newDigestServer(t, algorithm, "auth", &ncs)
+		s := NewSession()
+		s.Auth = NewDigestAuth("user", "pass")
+		for i := 0; i < 2; i++ {
+			resp, err := s.New(srv.URL).WithPath("/dir/index.html").WithParams(Vals{{"a", i}}).Get()
+			if err != nil {
+				t.Fatal(algorithm, err)
+			}
+			if resp.Text() != "ok" {
+				t.Fatal("Unexpected response:", resp.Text())
+			}
+		}
+		// the challenge is reused by the second request
+		if strings.Join(ncs, ",") != "00000001,00000002" {
+			t.Fatal("Unexpected nonce counts:", algorithm, ncs)
+		}
+	}
+}
+
+func TestReqSend_DigestAuthInt(t *testing.T) {
+	var ncs []string
+	srv := newDigestServer(t, "MD5", "auth-int", &ncs)
+	auth := NewDigestAuth("user", "pass")
+	auth.AuthInt = true
+	resp, err := New(srv.URL).WithAuth(auth).WithBody(`{"a":1}`).Post()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "ok" {
+		t.Fatal("Unexpected response:", resp.Text())
+	}
+}
+
+func TestReqSend_DigestAuthBadPassword(t *testing.T) {
+	var ncs []string
+	srv := newDigestServer(t, "MD5", "auth", &ncs)
+	resp, err := New(srv.URL).WithAuth(NewDigestAuth("user", "bad")).
+		WithLogger(NopLogger).Get()
+	if err == nil {
+		t.Fatal("Expected err, but got nil")
+	}
+	if resp.RespRaw.StatusCode != 401 {
+		t.Fatal("Unexpected status:", resp.RespRaw.StatusCode)
+	}
+}
+
+func TestParseChallenges(t *testing.T) {
+	cs := parseChallenges([]string{
+		`Newauth realm="apps", type=1, title="Login to \"apps\"", Basic realm="simple"`,
+		`Bearer abc==`,
+	})
+	got := fmt.Sprint(cs)
+	want := `[{Newauth map[realm:apps title:Login to "apps" type:1]} {Basic map[realm:simple]} {Bearer map[]}]`
+	if got != want {
+		t.Fatal("Unexpected challenges:", got)
+	}
+}
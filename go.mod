@@ -1,8 +1,9 @@
 module github.com/nordborn/go-req
 
-go 1.18
+go 1.21
 
 require (
+	github.com/klauspost/compress v1.17.11
 	github.com/nordborn/go-errow v1.0.1
 	github.com/nordborn/golog v0.0.0-20190110093311-983a5529802d
 )
@@ -0,0 +1,156 @@
+package req
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SignParts are parts of the request for canonical string
+type SignParts struct {
+	Method string
+	// Path is escaped path of the request URL
+	Path string
+	// Query is the raw query in the order of Params
+	// (with timestamp and nonce params if they are configured)
+	Query string
+	// Body is the in-memory request body
+	Body      string
+	Timestamp string
+	Nonce     string
+}
+
+// CanonicalTimestampMethodPath returns Timestamp + Method + Path
+// (with "?" and Query if present) + Body,
+// used by Coinbase-style APIs. It's the default HMACSigner.Canonical
+func CanonicalTimestampMethodPath(p SignParts) string {
+	path := p.Path
+	if p.Query != "" {
+		path += "?" + p.Query
+	}
+	return p.Timestamp + p.Method + path + p.Body
+}
+
+// CanonicalQueryBody returns Query + Body,
+// used by Binance-style APIs (with timestamp param)
+func CanonicalQueryBody(p SignParts) string {
+	return p.Query + p.Body
+}
+
+// HMACSigner is Auth which signs each attempt with HMAC
+// of canonical string (see SignParts) and puts the signature,
+// timestamp, monotonic nonce and API key into configured headers
+// or query params.
+// Example for Binance-style API:
+//
+//	signer := &req.HMACSigner{
+//		Secret:         []byte(secret),
+//		Canonical:      req.CanonicalQueryBody,
+//		KeyHeader:      "X-MBX-APIKEY",
+//		Key:            key,
+//		TimestampParam: "timestamp",
+//		SignatureParam: "signature",
+//	}
+//	r.WithAuth(signer)
+type HMACSigner struct {
+	// Secret is HMAC key
+	Secret []byte
+
+	// Hash is HMAC hash. Default is sha256.New (use sha512.New for SHA-512)
+	Hash func() hash.Hash
+
+	// Base64: base64 signature encoding instead of hex
+	Base64 bool
+
+	// Canonical builds string to sign.
+	// Default is CanonicalTimestampMethodPath
+	Canonical func(p SignParts) string
+
+	// Timestamp returns timestamp of each attempt.
+	// Default is Unix time in milliseconds
+	Timestamp func() string
+
+	// KeyHeader and Key: API key header (optional)
+	KeyHeader string
+	Key       string
+
+	// Headers or query params of timestamp, nonce and signature
+	// (empty names are not set)
+	TimestampHeader string
+	TimestampParam  string
+	NonceHeader     string
+	NonceParam      string
+	SignatureHeader string
+	SignatureParam  string
+
+	mu    sync.Mutex
+	nonce int64
+}
+
+// Authenticate implements Auth
+func (s *HMACSigner) Authenticate(request *http.Request) error {
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	if s.Timestamp != nil {
+		timestamp = s.Timestamp()
+	}
+	nonce := strconv.FormatInt(s.nextNonce(), 10)
+
+	query := request.URL.RawQuery
+	query = appendQuery(query, s.TimestampParam, timestamp)
+	query = appendQuery(query, s.NonceParam, nonce)
+	body, err := requestBody(request)
+	if err != nil {
+		return err
+	}
+
+	canonical := s.Canonical
+	if canonical == nil {
+		canonical = CanonicalTimestampMethodPath
+	}
+	newHash := s.Hash
+	if newHash == nil {
+		newHash = sha256.New
+	}
+	mac := hmac.New(newHash, s.Secret)
+	mac.Write([]byte(canonical(SignParts{
+		Method:    request.Method,
+		Path:      request.URL.EscapedPath(),
+		Query:     query,
+		Body:      string(body),
+		Timestamp: timestamp,
+		Nonce:     nonce,
+	})))
+	signature := hex.EncodeToString(mac.Sum(nil))
+	if s.Base64 {
+		signature = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	// the signature param is the last one
+	request.URL.RawQuery = appendQuery(query, s.SignatureParam, signature)
+	for _, h := range [][2]string{
+		{s.KeyHeader, s.Key},
+		{s.TimestampHeader, timestamp},
+		{s.NonceHeader, nonce},
+		{s.SignatureHeader, signature},
+	} {
+		if h[0] != "" {
+			request.Header.Set(h[0], h[1])
+		}
+	}
+	return nil
+}
+
+// nextNonce returns strictly increasing nonce
+// (Unix time in microseconds or the previous nonce + 1)
+func (s *HMACSigner) nextNonce() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nonce = max(s.nonce+1, time.Now().UnixMicro())
+	return s.nonce
+}
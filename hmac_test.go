@@ -0,0 +1,79 @@
+package req
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHMACSigner_Binance(t *testing.T) {
+	var query, key string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query, key = r.URL.RawQuery, r.Header.Get("X-MBX-APIKEY")
+	}))
+	defer srv.Close()
+
+	signer := &HMACSigner{
+		Secret:         []byte("NhqPtmdSJYdKjVHjA7PZj4Mge3R5YNiP1e3UZjInClVN65XAbvqqM6A7H5fATj0j"),
+		Canonical:      CanonicalQueryBody,
+		Timestamp:      func() string { return "1499827319559" },
+		KeyHeader:      "X-MBX-APIKEY",
+		Key:            "key",
+		TimestampParam: "timestamp",
+		SignatureParam: "signature",
+	}
+	_, err := New(srv.URL).WithAuth(signer).WithParams(Vals{
+		{"symbol", "LTCBTC"}, {"side", "BUY"}, {"type", "LIMIT"}, {"timeInForce", "GTC"},
+		{"quantity", 1}, {"price", 0.1}, {"recvWindow", 5000},
+	}).Post()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "symbol=LTCBTC&side=BUY&type=LIMIT&timeInForce=GTC&quantity=1&price=0.1&recvWindow=5000" +
+		"&timestamp=1499827319559&signature=c8db56825ae71d6d79447849e617115f4a920fa2acdcab2b053c4b2838bd6b71"
+	if query != want || key != "key" {
+		t.Fatal("Unexpected request:", query, key)
+	}
+}
+
+func TestHMACSigner_ResignOnRetry(t *testing.T) {
+	secret := []byte("secret")
+	var nonces []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, nonce := r.Header.Get("Api-Timestamp"), r.Header.Get("Api-Nonce")
+		mac := hmac.New(sha512.New, secret)
+		fmt.Fprint(mac, ts, r.Method, r.URL.Path, "?", r.URL.RawQuery, string(body))
+		if r.Header.Get("Api-Signature") != base64.StdEncoding.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		nonces = append(nonces, nonce)
+		if len(nonces) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	signer := &HMACSigner{
+		Secret:          secret,
+		Hash:            sha512.New,
+		Base64:          true,
+		TimestampHeader: "Api-Timestamp",
+		NonceHeader:     "Api-Nonce",
+		SignatureHeader: "Api-Signature",
+	}
+	_, err := New(srv.URL).WithPath("/orders").WithParams(Vals{{"b", 2}, {"a", 1}}).
+		WithBody(`{"qty":1}`).WithAuth(signer).WithAttempts(2).WithLogger(NopLogger).Post()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nonces) != 2 || nonces[1] <= nonces[0] {
+		t.Fatal("Unexpected nonces:", nonces)
+	}
+}
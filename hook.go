@@ -0,0 +1,60 @@
+package req
+
+// ResponseHook is called after each attempt response (transport errors
+// don't reach hooks) before retry evaluation.
+// It can inspect the response or replace it returning another Resp
+// (nil keeps the current one, nil RespRaw keeps the current RespRaw),
+// for example, to unwrap an API envelope.
+// It can modify Req fields (a.Req) and return Retry(reason)
+// to fail the attempt and retry with the new fields
+// (if attempts are left), or return any other error
+// to fail the request without retries.
+//
+// Example, refresh an expired token:
+//
+//	refresh := func(a *req.Attempt, resp *req.Resp) (*req.Resp, error) {
+//		if resp.RespRaw.StatusCode == http.StatusUnauthorized {
+//			a.Req.Headers.Set("Authorization", "Bearer "+newToken())
+//			return nil, req.Retry("token expired")
+//		}
+//		return nil, nil
+//	}
+//	r.WithAttempts(2).WithResponseHooks(refresh)
+type ResponseHook func(a *Attempt, resp *Resp) (*Resp, error)
+
+// retryError requests retry of the attempt
+type retryError struct {
+	reason string
+	// auth: retry with refreshed credentials (see RefreshableAuth),
+	// it doesn't consume Attempts
+	auth bool
+}
+
+func (e *retryError) Error() string {
+	return e.reason
+}
+
+// Retry returns error which fails the attempt with reason and
+// triggers retry (see ResponseHook)
+func Retry(reason string) error {
+	return &retryError{reason: reason}
+}
+
+// runResponseHooks applies ResponseHooks to resp.
+// On error the last response is returned with the error
+func (r *Req) runResponseHooks(a *Attempt, resp *Resp) (*Resp, error) {
+	for _, hook := range r.ResponseHooks {
+		next, err := hook(a, resp)
+		if next != nil {
+			if next.RespRaw == nil {
+				// transformers may replace the content only
+				next.RespRaw = resp.RespRaw
+			}
+			resp = next
+		}
+		if err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,96 @@
+package req
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReqSend_ResponseHookRetry(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer new" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+
+	metrics := &testMetrics{}
+	refresh := func(a *Attempt, resp *Resp) (*Resp, error) {
+		if resp.RespRaw.StatusCode == http.StatusUnauthorized {
+			a.Req.Headers.Set("Authorization", "Bearer new")
+			return nil, Retry("token expired")
+		}
+		return nil, nil
+	}
+	r := New(srv.URL).WithHeaders(Vals{{"Authorization", "Bearer old"}}).
+		WithAttempts(2).WithLogger(NopLogger).WithMetrics(metrics).
+		WithResponseHooks(refresh)
+	resp, err := r.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "ok" || len(resp.History) != 2 ||
+		resp.History[0].Reason != "token expired" || resp.History[0].Status != 401 {
+		t.Fatal("Unexpected response:", resp.Text(), resp.History)
+	}
+	if metrics.calls[1] != "attempt 1 401 hook" {
+		t.Fatal("Unexpected metrics:", metrics.calls)
+	}
+}
+
+func TestReqSend_ResponseHookTransform(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"name":"Bob"},"error":null}`)
+	}))
+	defer srv.Close()
+
+	unwrap := func(a *Attempt, resp *Resp) (*Resp, error) {
+		var envelope struct {
+			Data  json.RawMessage
+			Error *string
+		}
+		if err := resp.JSON(&envelope); err != nil {
+			return nil, err
+		}
+		if envelope.Error != nil {
+			return nil, errors.New(*envelope.Error)
+		}
+		return &Resp{Content: envelope.Data}, nil
+	}
+	// the envelope contains "error" text marker, data doesn't
+	resp, err := New(srv.URL).WithResponseHooks(unwrap).Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != `{"name":"Bob"}` || resp.RespRaw.StatusCode != 200 {
+		t.Fatal("Unexpected response:", resp.Text())
+	}
+}
+
+func TestReqSend_ResponseHookError(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, "encrypted")
+	}))
+	defer srv.Close()
+
+	fail := func(a *Attempt, resp *Resp) (*Resp, error) {
+		return nil, errors.New("can't decrypt")
+	}
+	resp, err := New(srv.URL).WithAttempts(3).WithLogger(NopLogger).
+		WithResponseHooks(fail).Get()
+	if err == nil {
+		t.Fatal("Expected err, but got nil")
+	}
+	if !strings.Contains(err.Error(), "can't decrypt") || calls != 1 ||
+		resp.Text() != "encrypted" {
+		t.Fatal("Unexpected result:", err, calls, resp.Text())
+	}
+}
@@ -0,0 +1,571 @@
+package req
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nordborn/go-errow"
+)
+
+// HTTP message signature algorithms (RFC 9421 3.3)
+const (
+	SigAlgEd25519   = "ed25519"
+	SigAlgECDSAP256 = "ecdsa-p256-sha256"
+	SigAlgRSAPSS    = "rsa-pss-sha512"
+	SigAlgHMAC      = "hmac-sha256"
+)
+
+// MessageKey is a key of HTTP message signatures
+type MessageKey struct {
+	// KeyID is keyid signature param
+	KeyID string
+
+	// Alg is one of SigAlg... Default is inferred from Key type
+	Alg string
+
+	// Key is ed25519.PrivateKey, *ecdsa.PrivateKey (P-256),
+	// *rsa.PrivateKey or []byte (HMAC) for signing
+	// and ed25519.PublicKey, *ecdsa.PublicKey, *rsa.PublicKey
+	// or []byte for verifying
+	Key any
+}
+
+// alg returns Alg or alg inferred from Key type
+func (k MessageKey) alg() string {
+	if k.Alg != "" {
+		return k.Alg
+	}
+	switch k.Key.(type) {
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		return SigAlgEd25519
+	case *ecdsa.PrivateKey, *ecdsa.PublicKey:
+		return SigAlgECDSAP256
+	case *rsa.PrivateKey, *rsa.PublicKey:
+		return SigAlgRSAPSS
+	case []byte:
+		return SigAlgHMAC
+	}
+	return ""
+}
+
+// sign signs data with the key
+func (k MessageKey) sign(data []byte) ([]byte, error) {
+	switch key := k.Key.(type) {
+	case ed25519.PrivateKey:
+		if k.alg() == SigAlgEd25519 {
+			return ed25519.Sign(key, data), nil
+		}
+	case *ecdsa.PrivateKey:
+		if k.alg() == SigAlgECDSAP256 && key.Curve == elliptic.P256() {
+			digest := sha256.Sum256(data)
+			r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+			if err != nil {
+				return nil, errow.Wrap(err)
+			}
+			// fixed size r || s
+			sig := make([]byte, 64)
+			r.FillBytes(sig[:32])
+			s.FillBytes(sig[32:])
+			return sig, nil
+		}
+	case *rsa.PrivateKey:
+		if k.alg() == SigAlgRSAPSS {
+			digest := sha512.Sum512(data)
+			sig, err := rsa.SignPSS(rand.Reader, key, crypto.SHA512, digest[:], &rsa.PSSOptions{SaltLength: 64})
+			return sig, errow.Wrap(err)
+		}
+	case []byte:
+		if k.alg() == SigAlgHMAC {
+			mac := hmac.New(sha256.New, key)
+			mac.Write(data)
+			return mac.Sum(nil), nil
+		}
+	}
+	return nil, errow.Newf("unsupported signing key %T for alg '%v'", k.Key, k.alg())
+}
+
+// verify verifies signature sig of data with the key
+func (k MessageKey) verify(data, sig []byte) error {
+	key := k.Key
+	if signer, ok := key.(crypto.Signer); ok {
+		key = signer.Public()
+	}
+	valid := false
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		valid = k.alg() == SigAlgEd25519 && ed25519.Verify(key, data, sig)
+	case *ecdsa.PublicKey:
+		if k.alg() == SigAlgECDSAP256 && len(sig) == 64 {
+			digest := sha256.Sum256(data)
+			r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+			valid = ecdsa.Verify(key, digest[:], r, s)
+		}
+	case *rsa.PublicKey:
+		if k.alg() == SigAlgRSAPSS {
+			digest := sha512.Sum512(data)
+			valid = rsa.VerifyPSS(key, crypto.SHA512, digest[:], sig,
+				&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto}) == nil
+		}
+	case []byte:
+		if k.alg() == SigAlgHMAC {
+			mac := hmac.New(sha256.New, key)
+			mac.Write(data)
+			valid = hmac.Equal(mac.Sum(nil), sig)
+		}
+	default:
+		return errow.Newf("unsupported verifying key %T", k.Key)
+	}
+	if !valid {
+		return errow.New("invalid signature")
+	}
+	return nil
+}
+
+// MessageSigner is Auth which signs each attempt with
+// HTTP message signature (RFC 9421): it sets Signature-Input
+// and Signature headers over Components.
+// Example:
+//
+//	signer := &req.MessageSigner{
+//		Key:           req.MessageKey{KeyID: "key1", Key: ed25519PrivateKey},
+//		ContentDigest: true,
+//	}
+//	r.WithAuth(signer)
+type MessageSigner struct {
+	Key MessageKey
+
+	// Label of the signature. Default is "sig1"
+	Label string
+
+	// Components are covered derived components (@method, @target-uri,
+	// @authority, @scheme, @path, @query, @request-target)
+	// and header names. Default is @method, @target-uri, @authority
+	// and content-digest (if set)
+	Components []string
+
+	// ContentDigest: set Content-Digest header (RFC 9530, sha-256)
+	// of in-memory body
+	ContentDigest bool
+
+	// AlgParam: add alg signature param
+	AlgParam bool
+
+	// Expires: add expires signature param (created + Expires)
+	Expires time.Duration
+
+	// Tag is tag signature param (optional)
+	Tag string
+
+	// now is used in tests
+	now func() time.Time
+}
+
+// Authenticate implements Auth
+func (s *MessageSigner) Authenticate(request *http.Request) error {
+	components := s.Components
+	if components == nil {
+		components = []string{"@method", "@target-uri", "@authority"}
+	}
+	if s.ContentDigest {
+		body, err := requestBody(request)
+		if err != nil {
+			return err
+		}
+		if body != nil {
+			sum := sha256.Sum256(body)
+			request.Header.Set("Content-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":")
+			if s.Components == nil {
+				components = append(components, "content-digest")
+			}
+		}
+	}
+
+	created := time.Now()
+	if s.now != nil {
+		created = s.now()
+	}
+	var params strings.Builder
+	params.WriteString("(")
+	for i, c := range components {
+		if i > 0 {
+			params.WriteString(" ")
+		}
+		params.WriteString(`"` + strings.ToLower(c) + `"`)
+	}
+	params.WriteString(");created=" + strconv.FormatInt(created.Unix(), 10))
+	if s.Expires > 0 {
+		params.WriteString(";expires=" + strconv.FormatInt(created.Add(s.Expires).Unix(), 10))
+	}
+	if s.Key.KeyID != "" {
+		params.WriteString(";keyid=" + quoteParam(s.Key.KeyID))
+	}
+	if s.AlgParam {
+		params.WriteString(";alg=" + quoteParam(s.Key.alg()))
+	}
+	if s.Tag != "" {
+		params.WriteString(";tag=" + quoteParam(s.Tag))
+	}
+
+	base, err := signatureBase(components, params.String(), request, nil)
+	if err != nil {
+		return err
+	}
+	sig, err := s.Key.sign([]byte(base))
+	if err != nil {
+		return err
+	}
+	label := s.Label
+	if label == "" {
+		label = "sig1"
+	}
+	request.Header.Set("Signature-Input", label+"="+params.String())
+	request.Header.Set("Signature", label+"=:"+base64.StdEncoding.EncodeToString(sig)+":")
+	return nil
+}
+
+// MessageVerifier verifies HTTP message signatures (RFC 9421)
+// and Content-Digest of responses
+type MessageVerifier struct {
+	// Key returns the verifying key by keyid param
+	Key func(keyID string) (MessageKey, error)
+
+	// Label of the verified signature. Default is the first one
+	Label string
+
+	// Required components must be covered by the signature
+	Required []string
+
+	// MaxAge: created param is required and must be within MaxAge
+	// (0 - no check)
+	MaxAge time.Duration
+
+	// AllowUnsignedContent: accept non-empty content (or Content-Digest)
+	// of responses which signature doesn't cover content-digest
+	AllowUnsignedContent bool
+}
+
+// Verify verifies signature and Content-Digest (if present)
+// of the response with content.
+// Signatures which cover no components are rejected.
+// Content is protected only by the signed content-digest component,
+// so content of responses without it is rejected
+// unless AllowUnsignedContent is set
+func (v *MessageVerifier) Verify(respRaw *http.Response, content []byte) error {
+	inputs := parseSFDictionary(respRaw.Header.Values("Signature-Input"))
+	sigs := parseSFDictionary(respRaw.Header.Values("Signature"))
+	label := v.Label
+	if label == "" && len(inputs) > 0 {
+		label = inputs[0][0]
+	}
+	input, sigValue := sfDictionaryValue(inputs, label), sfDictionaryValue(sigs, label)
+	if input == "" || sigValue == "" {
+		return errow.Newf("no signature '%v'", label)
+	}
+	sig, err := sfByteSequence(sigValue)
+	if err != nil {
+		return err
+	}
+
+	components, params, err := parseSignatureInput(input)
+	if err != nil {
+		return err
+	}
+	if len(components) == 0 {
+		return errow.New("signature covers no components")
+	}
+	for _, required := range v.Required {
+		if !containsFold(components, required) {
+			return errow.Newf("component '%v' is not covered", required)
+		}
+	}
+	if expires, ok := params["expires"]; ok {
+		if t, _ := strconv.ParseInt(expires, 10, 64); time.Now().Unix() > t {
+			return errow.New("signature expired")
+		}
+	}
+	if v.MaxAge > 0 {
+		created, err := strconv.ParseInt(params["created"], 10, 64)
+		if err != nil {
+			return errow.New("no created param")
+		}
+		if time.Since(time.Unix(created, 0)) > v.MaxAge {
+			return errow.New("signature is too old")
+		}
+	}
+
+	key, err := v.Key(params["keyid"])
+	if err != nil {
+		return errow.Wrap(err, "no key")
+	}
+	if alg, ok := params["alg"]; ok && alg != key.alg() {
+		return errow.Newf("unexpected alg '%v'", alg)
+	}
+	base, err := signatureBase(components, input, nil, respRaw)
+	if err != nil {
+		return err
+	}
+	if err = key.verify([]byte(base), sig); err != nil {
+		return err
+	}
+	digests := respRaw.Header.Values("Content-Digest")
+	if !v.AllowUnsignedContent && !containsFold(components, "content-digest") &&
+		(len(content) > 0 || len(digests) > 0) {
+		return errow.New("content is not covered by signature")
+	}
+	return verifyContentDigest(digests, content)
+}
+
+// ResponseHook verifies responses (see ResponseHook):
+// r.WithResponseHooks(verifier.ResponseHook)
+func (v *MessageVerifier) ResponseHook(_ *Attempt, resp *Resp) (*Resp, error) {
+	if err := v.Verify(resp.RespRaw, resp.Content); err != nil {
+		return nil, errow.Wrap(err, "bad message signature")
+	}
+	return nil, nil
+}
+
+// verifyContentDigest checks supported digests of Content-Digest values
+func verifyContentDigest(values []string, content []byte) error {
+	for _, d := range parseSFDictionary(values) {
+		var sum []byte
+		switch d[0] {
+		case "sha-256":
+			s := sha256.Sum256(content)
+			sum = s[:]
+		case "sha-512":
+			s := sha512.Sum512(content)
+			sum = s[:]
+		default:
+			continue
+		}
+		expected, err := sfByteSequence(d[1])
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal(sum, expected) {
+			return errow.Newf("%v content digest mismatch", d[0])
+		}
+	}
+	return nil
+}
+
+// signatureBase returns signature base (RFC 9421 2.5) of request
+// or response (if respRaw is not nil)
+func signatureBase(components []string, params string, request *http.Request, respRaw *http.Response) (string, error) {
+	var b strings.Builder
+	for _, c := range components {
+		value, err := componentValue(strings.ToLower(c), request, respRaw)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(`"` + strings.ToLower(c) + `": ` + value + "\n")
+	}
+	b.WriteString(`"@signature-params": ` + params)
+	return b.String(), nil
+}
+
+// componentValue returns value of derived component or header
+func componentValue(name string, request *http.Request, respRaw *http.Response) (string, error) {
+	var header http.Header
+	if respRaw != nil {
+		header = respRaw.Header
+		if name == "@status" {
+			return strconv.Itoa(respRaw.StatusCode), nil
+		}
+	} else {
+		header = request.Header
+		u := request.URL
+		switch name {
+		case "@method":
+			return strings.ToUpper(request.Method), nil
+		case "@target-uri":
+			return strings.ToLower(u.Scheme) + "://" + requestHost(request) + u.RequestURI(), nil
+		case "@authority":
+			return strings.ToLower(requestHost(request)), nil
+		case "@scheme":
+			return strings.ToLower(u.Scheme), nil
+		case "@path":
+			if u.EscapedPath() == "" {
+				return "/", nil
+			}
+			return u.EscapedPath(), nil
+		case "@query":
+			return "?" + u.RawQuery, nil
+		case "@request-target":
+			return u.RequestURI(), nil
+		case "content-length":
+			if header.Get(name) == "" && request.ContentLength > 0 {
+				return strconv.FormatInt(request.ContentLength, 10), nil
+			}
+		}
+	}
+	if strings.HasPrefix(name, "@") {
+		return "", errow.Newf("unsupported component '%v'", name)
+	}
+	values := header.Values(name)
+	if len(values) == 0 {
+		return "", errow.Newf("no header '%v'", name)
+	}
+	// values are shared with the header
+	trimmed := make([]string, len(values))
+	for i, v := range values {
+		trimmed[i] = strings.TrimSpace(v)
+	}
+	return strings.Join(trimmed, ", "), nil
+}
+
+// parseSignatureInput returns components and params of signature input
+// (inner list of strings with params, RFC 8941 3.1.1).
+// Components with params (like "@query-param";name="n") are not supported
+func parseSignatureInput(input string) ([]string, map[string]string, error) {
+	if !strings.HasPrefix(input, "(") {
+		return nil, nil, errow.Newf("bad signature input '%v'", input)
+	}
+	var components []string
+	rest := input[1:]
+	for {
+		rest = strings.TrimLeft(rest, " ")
+		if strings.HasPrefix(rest, ")") {
+			break
+		}
+		component, more, err := cutSFString(rest)
+		if err != nil {
+			return nil, nil, errow.Wrapf(err, "bad signature input '%v'", input)
+		}
+		if more != "" && more[0] != ' ' && more[0] != ')' {
+			return nil, nil, errow.Newf("unsupported component in signature input '%v'", input)
+		}
+		components = append(components, component)
+		rest = more
+	}
+	params, err := parseSFParams(rest[1:])
+	if err != nil {
+		return nil, nil, errow.Wrapf(err, "bad signature input '%v'", input)
+	}
+	return components, params, nil
+}
+
+// parseSFParams parses structured field parameters (;key=value...).
+// Strings are unquoted, other bare items are kept as is,
+// params without value are "?1" (true)
+func parseSFParams(s string) (map[string]string, error) {
+	params := map[string]string{}
+	for s != "" {
+		if s[0] != ';' {
+			return nil, errow.Newf("bad params '%v'", s)
+		}
+		s = strings.TrimLeft(s[1:], " ")
+		n := 0
+		for n < len(s) && (s[n] >= 'a' && s[n] <= 'z' || s[n] >= '0' && s[n] <= '9' ||
+			strings.IndexByte("_-.*", s[n]) >= 0) {
+			n++
+		}
+		if n == 0 {
+			return nil, errow.Newf("bad param key '%v'", s)
+		}
+		key, value := s[:n], "?1"
+		s = s[n:]
+		if strings.HasPrefix(s, "=") {
+			var err error
+			if strings.HasPrefix(s, `="`) {
+				if value, s, err = cutSFString(s[1:]); err != nil {
+					return nil, err
+				}
+			} else {
+				end := strings.IndexAny(s, "; ")
+				if end < 0 {
+					end = len(s)
+				}
+				value, s = s[1:end], s[end:]
+			}
+		}
+		params[key] = value
+		s = strings.TrimLeft(s, " ")
+	}
+	return params, nil
+}
+
+// cutSFString cuts structured field string ("..." with \" and \\ escapes)
+// from the beginning of s and returns its unescaped value and the rest
+func cutSFString(s string) (string, string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", "", errow.Newf("not a string '%v'", s)
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+			if i == len(s) || (s[i] != '"' && s[i] != '\\') {
+				return "", "", errow.Newf("bad escape in string '%v'", s)
+			}
+		case '"':
+			return b.String(), s[i+1:], nil
+		}
+		b.WriteByte(s[i])
+	}
+	return "", "", errow.Newf("unterminated string '%v'", s)
+}
+
+// parseSFDictionary splits structured field dictionary (RFC 8941)
+// into [name, raw value] members
+func parseSFDictionary(values []string) [][2]string {
+	var members [][2]string
+	for _, s := range values {
+		start, quoted := 0, false
+		for i := 0; i <= len(s); i++ {
+			if i < len(s) {
+				switch {
+				case s[i] == '\\' && quoted:
+					i++
+					continue
+				case s[i] == '"':
+					quoted = !quoted
+				}
+				if quoted || s[i] != ',' {
+					continue
+				}
+			}
+			if k, v, ok := strings.Cut(strings.TrimSpace(s[start:i]), "="); ok {
+				members = append(members, [2]string{k, v})
+			}
+			start = i + 1
+		}
+	}
+	return members
+}
+
+// sfDictionaryValue returns raw value of dictionary member
+func sfDictionaryValue(members [][2]string, name string) string {
+	for _, m := range members {
+		if m[0] == name {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// sfByteSequence decodes structured field byte sequence ":base64:"
+func sfByteSequence(s string) ([]byte, error) {
+	if len(s) < 2 || s[0] != ':' || s[len(s)-1] != ':' {
+		return nil, errow.Newf("bad byte sequence '%v'", s)
+	}
+	b, err := base64.StdEncoding.DecodeString(s[1 : len(s)-1])
+	if err != nil {
+		return nil, errow.Wrap(err, "bad byte sequence")
+	}
+	return b, nil
+}
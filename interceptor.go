@@ -0,0 +1,154 @@
+package req
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/nordborn/go-errow"
+)
+
+// Attempt is passed to interceptors of each attempt
+type Attempt struct {
+	// Num is the number of the attempt starting from 1
+	Num int
+	// Req is the request being sent
+	Req *Req
+	// Request is the built request of the attempt.
+	// Interceptors can modify or replace it before calling next
+	Request *http.Request
+	// RequestID is the request ID (see Req.RequestIDFunc)
+	RequestID string
+	// Prev and PrevErr are the result of the previous attempt
+	// (nil for the first attempt)
+	Prev    *Resp
+	PrevErr error
+
+	// authRetry: the attempt follows refresh of credentials
+	authRetry   bool
+	ctx         context.Context
+	span        RequestSpan
+	attemptSpan AttemptSpan
+	dbg         *debugAttempt
+	tt          *timingTrace
+}
+
+// Rebuild builds Request again from Req fields
+// (use it after modifying Req fields in an interceptor)
+func (a *Attempt) Rebuild() error {
+	// release the previous body (like compression pipe of streamed body)
+	closeBody(a.Request)
+	if a.Req.reqRaw != a.Request {
+		closeBody(a.Req.reqRaw)
+	}
+	if _, err := a.Req.prepareReqRaw(a.ctx, a.RequestID, a.Num); err != nil {
+		return err
+	}
+	a.Request = a.Req.reqRaw
+	return nil
+}
+
+// closeBody closes body of not sent request
+func closeBody(request *http.Request) {
+	if request != nil && request.Body != nil {
+		request.Body.Close()
+	}
+}
+
+// Handler performs an attempt and returns its response
+// (Resp.Content and Resp.RespRaw) or the transport error
+type Handler func(ctx context.Context, a *Attempt) (*Resp, error)
+
+// Interceptor wraps Handler of each attempt (the first one is the outermost).
+// It can inspect and modify the attempt before calling next,
+// inspect the response or error after it,
+// or short-circuit the attempt returning its own response
+// (with RespRaw) without calling next.
+// Returned errors are treated as transport errors (retry attempt),
+// errors wrapped with Abort stop the request without retries.
+//
+// Example:
+//
+//	logStatus := func(next req.Handler) req.Handler {
+//		return func(ctx context.Context, a *req.Attempt) (*req.Resp, error) {
+//			a.Request.Header.Set("Nonce", fmt.Sprint(time.Now().UnixNano()))
+//			resp, err := next(ctx, a)
+//			if err == nil {
+//				log.Println(a.Num, resp.RespRaw.StatusCode)
+//			}
+//			return resp, err
+//		}
+//	}
+//	r.Interceptors = []req.Interceptor{logStatus}
+type Interceptor func(next Handler) Handler
+
+// abortError stops the request without retries
+type abortError struct {
+	err error
+}
+
+func (e *abortError) Error() string {
+	return "aborted: " + e.err.Error()
+}
+
+// Abort wraps err returned by an interceptor to stop the request
+// without retries
+func Abort(err error) error {
+	return &abortError{err}
+}
+
+// MiddlewareFunc adapts legacy middleware (func modifying Req fields)
+// to Interceptor: it calls f and rebuilds the request
+func MiddlewareFunc(f func()) Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, a *Attempt) (*Resp, error) {
+			f()
+			if err := a.Rebuild(); err != nil {
+				return nil, Abort(err)
+			}
+			return next(ctx, a)
+		}
+	}
+}
+
+// handler returns chain of Interceptors with transport at the end.
+// 401 response fails the attempt with auth retry error
+// if Auth is RefreshableAuth which refreshed credentials
+// (not for the attempt which follows the refresh)
+func (r *Req) handler() Handler {
+	h := Handler(r.transport)
+	for i := len(r.Interceptors) - 1; i >= 0; i-- {
+		h = r.Interceptors[i](h)
+	}
+	return func(ctx context.Context, a *Attempt) (*Resp, error) {
+		resp, err := h(ctx, a)
+		if err == nil && (resp == nil || resp.RespRaw == nil) {
+			return resp, Abort(errow.New("interceptor returned no response"))
+		}
+		if ra, ok := r.Auth.(RefreshableAuth); ok && err == nil && !a.authRetry &&
+			resp.RespRaw.StatusCode == http.StatusUnauthorized &&
+			ra.Refresh(a.Request, resp.RespRaw) {
+			return resp, &retryError{reason: "credentials refreshed after status code '401'", auth: true}
+		}
+		return resp, err
+	}
+}
+
+// transport is the final Handler: it sends the request
+// with tracing, debug and timing hooks
+func (r *Req) transport(ctx context.Context, a *Attempt) (*Resp, error) {
+	request := a.Request
+	if request.Context() != ctx {
+		request = request.WithContext(ctx)
+	}
+	if a.span != nil {
+		request, a.attemptSpan = a.span.StartAttempt(ctx, a.Num, request)
+	}
+	if r.Debug != nil {
+		request, a.dbg = r.Debug.start(request)
+	}
+	request, a.tt = startTiming(request)
+	r.reqRaw = request
+
+	respRaw, content, err := r.do(a.tt)
+	return &Resp{Content: content, RespRaw: respRaw, RequestID: a.RequestID}, err
+}
@@ -0,0 +1,126 @@
+package req
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReqSend_Interceptors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Attempt") == "1" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		fmt.Fprint(w, strings.Join(r.Header.Values("Order"), ","))
+	}))
+	defer srv.Close()
+
+	var calls []string
+	order := func(name string) Interceptor {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, a *Attempt) (*Resp, error) {
+				a.Request.Header.Add("Order", name)
+				resp, err := next(ctx, a)
+				calls = append(calls, fmt.Sprint(name, a.Num, resp.RespRaw.StatusCode, a.Prev != nil))
+				return resp, err
+			}
+		}
+	}
+	attemptNum := func(next Handler) Handler {
+		return func(ctx context.Context, a *Attempt) (*Resp, error) {
+			a.Request.Header.Set("Attempt", fmt.Sprint(a.Num))
+			return next(ctx, a)
+		}
+	}
+
+	r := New(srv.URL).WithAttempts(2).WithLogger(NopLogger).
+		WithInterceptors(order("a"), order("b"), attemptNum)
+	resp, err := r.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "a,b" || len(resp.History) != 2 {
+		t.Fatal("Unexpected response:", resp.Text(), resp.History)
+	}
+	if strings.Join(calls, ",") != "b1 503 false,a1 503 false,b2 200 true,a2 200 true" {
+		t.Fatal("Unexpected calls:", calls)
+	}
+}
+
+func TestReqSend_InterceptorShortCircuit(t *testing.T) {
+	cached := func(next Handler) Handler {
+		return func(ctx context.Context, a *Attempt) (*Resp, error) {
+			respRaw := &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader("")),
+				Request:    a.Request,
+			}
+			return &Resp{Content: []byte("cached"), RespRaw: respRaw}, nil
+		}
+	}
+
+	resp, err := New("http://127.0.0.1:1").WithInterceptors(cached).Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "cached" || len(resp.History) != 1 || resp.History[0].Status != 200 {
+		t.Fatal("Unexpected response:", resp.Text(), resp.History)
+	}
+}
+
+func TestReqSend_InterceptorAbort(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer srv.Close()
+
+	retried := 0
+	retry := func(next Handler) Handler {
+		return func(ctx context.Context, a *Attempt) (*Resp, error) {
+			if a.Num == 1 {
+				retried++
+				return nil, errors.New("temporary")
+			}
+			return nil, Abort(errors.New("no quota"))
+		}
+	}
+	resp, err := New(srv.URL).WithAttempts(5).WithLogger(NopLogger).
+		WithInterceptors(retry).Get()
+	if err == nil {
+		t.Fatal("Expected err, but got nil")
+	}
+	if !strings.Contains(err.Error(), "aborted: no quota") ||
+		len(resp.History) != 2 || retried != 1 || calls != 0 {
+		t.Fatal("Unexpected result:", err, resp.History, retried, calls)
+	}
+}
+
+func TestMiddlewareFunc(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.URL.Query().Get("n"))
+	}))
+	defer srv.Close()
+
+	r := New(srv.URL)
+	n := 0
+	r.Interceptors = []Interceptor{MiddlewareFunc(func() {
+		n++
+		r.Params = Vals{{"n", n}}
+	})}
+	for i := 1; i <= 2; i++ {
+		resp, err := r.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text() != fmt.Sprint(i) {
+			t.Fatal("Unexpected response:", resp.Text())
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package req
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/nordborn/golog"
+)
+
+// LogLevel is a level of Logger records
+type LogLevel int
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+// String returns name of the level
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "DEBUG"
+	case LogInfo:
+		return "INFO"
+	case LogWarn:
+		return "WARN"
+	case LogError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LogLevel(%d)", int(l))
+}
+
+// Logger receives structured log records produced by Send.
+// Fields are ordered key-value pairs: method, url (Path template,
+// not the final URL), attempt, status, duration, reason, etc.
+// Set it per Req (Req.Logger) or per Session (Session.Logger)
+type Logger interface {
+	Log(ctx context.Context, level LogLevel, msg string, fields Vals)
+}
+
+var (
+	// DefaultLogger is used if Req.Logger is nil.
+	// It writes records via github.com/nordborn/golog
+	DefaultLogger Logger = GologLogger{}
+
+	// NopLogger discards all records
+	NopLogger Logger = nopLogger{}
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Log(context.Context, LogLevel, string, Vals) {}
+
+// GologLogger writes records via github.com/nordborn/golog
+// as "msg key=value ..." lines: LogDebug records are written
+// with golog.Trace, others with golog.Info, golog.Warning, golog.Error
+type GologLogger struct{}
+
+// Log implements Logger
+func (GologLogger) Log(_ context.Context, level LogLevel, msg string, fields Vals) {
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, f := range fields {
+		fmt.Fprintf(&b, " %v=%v", f.K, f.V)
+	}
+	line := b.String()
+	switch level {
+	case LogDebug:
+		golog.Traceln(line)
+	case LogInfo:
+		golog.Infoln(line)
+	case LogWarn:
+		golog.Warningln(line)
+	default:
+		golog.Errorln(line)
+	}
+}
+
+// SlogLogger adapts *slog.Logger to Logger,
+// fields are converted to slog attributes
+type SlogLogger struct {
+	Logger *slog.Logger
+}
+
+// NewSlogLogger returns Logger writing to l
+// (slog.Default() if l is nil)
+func NewSlogLogger(l *slog.Logger) *SlogLogger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &SlogLogger{Logger: l}
+}
+
+// Log implements Logger
+func (l *SlogLogger) Log(ctx context.Context, level LogLevel, msg string, fields Vals) {
+	attrs := make([]slog.Attr, len(fields))
+	for i, f := range fields {
+		attrs[i] = slog.Any(f.K, f.V)
+	}
+	l.Logger.LogAttrs(ctx, slogLevel(level), msg, attrs...)
+}
+
+// slogLevel converts LogLevel to slog.Level
+func slogLevel(level LogLevel) slog.Level {
+	switch level {
+	case LogDebug:
+		return slog.LevelDebug
+	case LogInfo:
+		return slog.LevelInfo
+	case LogWarn:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
+
+// logger returns Req.Logger or DefaultLogger
+func (r *Req) logger() Logger {
+	if r.Logger != nil {
+		return r.Logger
+	}
+	return DefaultLogger
+}
+
+// urlTemplate joins base URL and Path template for logs and metrics labels
+// (placeholders are not expanded, Params are not added)
+func urlTemplate(base, path string) string {
+	if path == "" {
+		return base
+	}
+	if strings.Contains(path, "://") {
+		return path
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// redactURLTemplate redacts userinfo and query params of URL template
+// keeping placeholders of its path unescaped
+func redactURLTemplate(rd *Redactor, tmpl string) string {
+	rest, query, hasQuery := strings.Cut(tmpl, "?")
+	origin, path := "", rest
+	if i := strings.Index(rest, "://"); i >= 0 {
+		origin, path = rest, ""
+		if j := strings.Index(rest[i+3:], "/"); j >= 0 {
+			origin, path = rest[:i+3+j], rest[i+3+j:]
+		}
+		origin = rd.URL(origin)
+	}
+	s := origin + path
+	if hasQuery {
+		s += "?" + rd.query(query)
+	}
+	return rd.Text(s)
+}
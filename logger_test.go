@@ -0,0 +1,111 @@
+package req
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testLogger records all log records
+type testLogger struct {
+	mu      sync.Mutex
+	records []testLogRecord
+}
+
+type testLogRecord struct {
+	level  LogLevel
+	msg    string
+	fields Vals
+}
+
+func (l *testLogger) Log(_ context.Context, level LogLevel, msg string, fields Vals) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, testLogRecord{level, msg, fields})
+}
+
+func TestReqSend_Logger(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	logger := &testLogger{}
+	r := New(srv.URL).WithLogger(logger).WithAttempts(2).
+		WithPathParams("/users/{id}", Vals{{"id", 1}})
+	resp, err := r.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := []string{}
+	for _, rec := range logger.records {
+		msgs = append(msgs, rec.level.String()+" "+rec.msg)
+	}
+	if strings.Join(msgs, ";") != "DEBUG do request;WARN attempt failed;DEBUG do request;DEBUG request succeeded" {
+		t.Fatal("Unexpected records:", msgs)
+	}
+	failed := logger.records[1].fields
+	if failed.Get("url") != srv.URL+"/users/{id}" || failed.Get("status") != 503 || failed.Get("attempt") != 1 {
+		t.Fatal("Unexpected fields:", failed)
+	}
+
+	if len(resp.History) != 2 || resp.History[0].Status != 503 ||
+		resp.History[0].Reason == "" || resp.History[1].Reason != "" {
+		t.Fatal("Unexpected history:", resp.History)
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	logger.Log(context.Background(), LogWarn, "attempt failed", Vals{{"method", "GET"}, {"status", 503}})
+	if !strings.Contains(buf.String(), `level=WARN msg="attempt failed" method=GET status=503`) {
+		t.Fatal("Unexpected record:", buf.String())
+	}
+}
+
+func TestNopLogger(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	if _, err := New(srv.URL).WithLogger(NopLogger).Get(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReqSend_LoggerRedactsURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	logger := &testLogger{}
+	base := strings.Replace(srv.URL, "://", "://user:hunter2@", 1)
+	_, err := New(base).WithLogger(logger).WithPathParams("/users/{id}", Vals{{"id", 1}}).Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = New(base + "/items?token=secret&a=1").WithLogger(logger).Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	masked := strings.Replace(srv.URL, "://", "://user:[REDACTED]@", 1)
+	urls := []any{logger.records[0].fields.Get("url"), logger.records[2].fields.Get("url")}
+	if fmt.Sprint(urls) != fmt.Sprint([]string{masked + "/users/{id}", masked + "/items?token=[REDACTED]&a=1"}) {
+		t.Fatal("Unexpected urls:", urls)
+	}
+
+	_, err = New(srv.URL).WithProxyURL("http://user:hunter2@ip:port").WithLogger(NopLogger).Get()
+	if err == nil || strings.Contains(err.Error(), "hunter2") {
+		t.Fatal("Unexpected proxy err:", err)
+	}
+}
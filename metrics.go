@@ -0,0 +1,55 @@
+package req
+
+import (
+	"net/url"
+	"time"
+)
+
+// Retry reasons passed to Metrics.AttemptDone
+const (
+	RetryReasonError      = "error"
+	RetryReasonStatusCode = "status_code"
+	RetryReasonTextMarker = "text_marker"
+	RetryReasonHook       = "hook"
+	RetryReasonAuth       = "auth"
+)
+
+// MetricLabels identify requests in Metrics.
+// Path is the Path template (not the final path),
+// so it has low cardinality
+type MetricLabels struct {
+	Method string
+	Host   string
+	Path   string
+}
+
+// Metrics receives measurements of Send.
+// Set it per Req (Req.Metrics) or per Session (Session.Metrics).
+// See github.com/nordborn/go-req/reqprom module for Prometheus adapter
+type Metrics interface {
+	// RequestStarted is called once per Send before the first attempt
+	// (in-flight requests)
+	RequestStarted(labels MetricLabels)
+
+	// AttemptDone is called after each attempt.
+	// Status is 0 if there is no response,
+	// retry is one of RetryReason... if the next attempt follows, otherwise ""
+	AttemptDone(labels MetricLabels, attempt, status int, duration time.Duration, retry string)
+
+	// RequestDone is called once per Send after the last attempt
+	// with status of the last response (0 if there is no response),
+	// number of attempts, total duration and the resulting error
+	RequestDone(labels MetricLabels, status, attempts int, duration time.Duration, err error)
+}
+
+// metricLabels returns labels of the request
+func (r *Req) metricLabels() MetricLabels {
+	labels := MetricLabels{Method: r.Method, Path: r.Path}
+	if u, err := url.Parse(r.URL); err == nil {
+		labels.Host = u.Host
+		if labels.Path == "" {
+			labels.Path = u.Path
+		}
+	}
+	return labels
+}
@@ -0,0 +1,58 @@
+package req
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testMetrics records calls of Metrics
+type testMetrics struct {
+	calls []string
+}
+
+func (m *testMetrics) RequestStarted(l MetricLabels) {
+	m.calls = append(m.calls, fmt.Sprint("started ", l.Method, " ", l.Path))
+}
+
+func (m *testMetrics) AttemptDone(_ MetricLabels, attempt, status int, _ time.Duration, retry string) {
+	m.calls = append(m.calls, fmt.Sprint("attempt ", attempt, " ", status, " ", retry))
+}
+
+func (m *testMetrics) RequestDone(_ MetricLabels, status, attempts int, _ time.Duration, err error) {
+	m.calls = append(m.calls, fmt.Sprint("done ", status, " ", attempts, " ", err != nil))
+}
+
+func TestReqSend_Metrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	m := &testMetrics{}
+	r := New(srv.URL).WithMetrics(m).WithAttempts(2).WithLogger(NopLogger).WithPath("/items")
+	if _, err := r.Get(); err == nil {
+		t.Fatal("Expected err, but got nil")
+	}
+	expected := fmt.Sprint([]string{
+		"started GET /items",
+		"attempt 1 500 status_code",
+		"attempt 2 500 ",
+		"done 500 2 true",
+	})
+	if fmt.Sprint(m.calls) != expected {
+		t.Fatal("Unexpected calls:", m.calls)
+	}
+
+	// bad request is also reported
+	m.calls = nil
+	r.PathParams = Vals{{"id", 1}}
+	if _, err := r.Get(); err == nil {
+		t.Fatal("Expected err, but got nil")
+	}
+	if fmt.Sprint(m.calls) != fmt.Sprint([]string{"started GET /items", "done 0 0 true"}) {
+		t.Fatal("Unexpected calls:", m.calls)
+	}
+}
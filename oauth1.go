@@ -0,0 +1,171 @@
+package req
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nordborn/go-errow"
+)
+
+// OAuth 1.0a signature methods
+const (
+	OAuth1HMACSHA1  = "HMAC-SHA1"
+	OAuth1RSASHA1   = "RSA-SHA1"
+	OAuth1Plaintext = "PLAINTEXT"
+)
+
+// OAuth1 is Auth which signs each attempt with OAuth 1.0a (RFC 5849).
+// Signature base string includes query params (Params) and
+// form body params (Form or body with "application/x-www-form-urlencoded"
+// Content-Type header). Compressed form body can't be signed
+type OAuth1 struct {
+	ConsumerKey    string
+	ConsumerSecret string
+	// Token and TokenSecret are empty for temporary credentials request
+	Token       string
+	TokenSecret string
+
+	// SignatureMethod: OAuth1HMACSHA1 (default), OAuth1RSASHA1
+	// (with PrivateKey) or OAuth1Plaintext
+	SignatureMethod string
+	PrivateKey      *rsa.PrivateKey
+
+	// Realm of Authorization header (optional)
+	Realm string
+
+	// Callback and Verifier of temporary credentials
+	// and token requests (optional)
+	Callback string
+	Verifier string
+
+	// now and nonce are used in tests
+	now   func() time.Time
+	nonce func() string
+}
+
+// Authenticate implements Auth
+func (o *OAuth1) Authenticate(request *http.Request) error {
+	method := o.SignatureMethod
+	if method == "" {
+		method = OAuth1HMACSHA1
+	}
+	now, nonce := time.Now, func() string { return randomString(16) }
+	if o.now != nil {
+		now = o.now
+	}
+	if o.nonce != nil {
+		nonce = o.nonce
+	}
+
+	oauthParams := Vals{
+		{"oauth_consumer_key", o.ConsumerKey},
+		{"oauth_nonce", nonce()},
+		{"oauth_signature_method", method},
+		{"oauth_timestamp", strconv.FormatInt(now().Unix(), 10)},
+		{"oauth_version", "1.0"},
+	}
+	if o.Token != "" {
+		oauthParams.Add("oauth_token", o.Token)
+	}
+	if o.Callback != "" {
+		oauthParams.Add("oauth_callback", o.Callback)
+	}
+	if o.Verifier != "" {
+		oauthParams.Add("oauth_verifier", o.Verifier)
+	}
+
+	key := escapeRFC3986(o.ConsumerSecret) + "&" + escapeRFC3986(o.TokenSecret)
+	var signature string
+	switch method {
+	case OAuth1Plaintext:
+		signature = key
+	case OAuth1HMACSHA1, OAuth1RSASHA1:
+		base, err := oauth1BaseString(request, oauthParams)
+		if err != nil {
+			return err
+		}
+		if method == OAuth1HMACSHA1 {
+			mac := hmac.New(sha1.New, []byte(key))
+			mac.Write([]byte(base))
+			signature = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+			break
+		}
+		if o.PrivateKey == nil {
+			return errow.New("no private key for RSA-SHA1")
+		}
+		sum := sha1.Sum([]byte(base))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, o.PrivateKey, crypto.SHA1, sum[:])
+		if err != nil {
+			return errow.Wrap(err, "can't sign")
+		}
+		signature = base64.StdEncoding.EncodeToString(sig)
+	default:
+		return errow.Newf("unsupported signature method '%v'", method)
+	}
+	oauthParams.Add("oauth_signature", signature)
+
+	var b strings.Builder
+	b.WriteString("OAuth ")
+	if o.Realm != "" {
+		fmt.Fprintf(&b, `realm=%v, `, quoteParam(o.Realm))
+	}
+	for i, v := range oauthParams {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, `%v="%v"`, v.K, escapeRFC3986(fmt.Sprint(v.V)))
+	}
+	request.Header.Set("Authorization", b.String())
+	return nil
+}
+
+// oauth1BaseString returns signature base string (RFC 5849 3.4.1)
+// of request with oauthParams
+func oauth1BaseString(request *http.Request, oauthParams Vals) (string, error) {
+	params, err := ParseVals(request.URL.RawQuery)
+	if err != nil {
+		return "", errow.Wrap(err, "bad query")
+	}
+	mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if mediaType == "application/x-www-form-urlencoded" {
+		if enc := request.Header.Get("Content-Encoding"); enc != "" && enc != "identity" {
+			return "", errow.Newf("%v compressed form body can't be signed", enc)
+		}
+		body, err := requestBody(request)
+		if err != nil {
+			return "", err
+		}
+		form, err := ParseVals(string(body))
+		if err != nil {
+			return "", errow.Wrap(err, "bad form body")
+		}
+		params = params.Extend(form)
+	}
+	params = params.Extend(oauthParams)
+
+	pairs := make([][2]string, len(params))
+	for i, v := range params {
+		pairs[i] = [2]string{escapeRFC3986(v.K), escapeRFC3986(fmt.Sprint(v.V))}
+	}
+
+	path := request.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	baseURI := strings.ToLower(request.URL.Scheme) + "://" + strings.ToLower(requestHost(request)) + path
+	return strings.Join([]string{
+		strings.ToUpper(request.Method),
+		escapeRFC3986(baseURI),
+		escapeRFC3986(joinSortedPairs(pairs)),
+	}, "&"), nil
+}
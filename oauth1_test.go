@@ -0,0 +1,124 @@
+package req
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOAuth1_HMACSHA1(t *testing.T) {
+	// Twitter documentation example
+	o := &OAuth1{
+		ConsumerKey:    "xvz1evFS4wEEPTGEFPHBog",
+		ConsumerSecret: "kAcSOqF21Fu85e7zjz7ZN2U4ZRhfV3WpwPAoE3Z7kBw",
+		Token:          "370773112-GmHxMAgYyLbNEtIKZeRNFsMKPR9EyMZeS9weJAEb",
+		TokenSecret:    "LswwdoUaIvS8ltyTt5jkRh4J50vUPVVHtR2YPi5kE",
+		now:            func() time.Time { return time.Unix(1318622958, 0) },
+		nonce:          func() string { return "kYjzVBB8Y0ZFabxSWbWovY3uYSQ2pTgmZeNu2VS4cg" },
+	}
+	request, _ := http.NewRequest("POST", "https://api.twitter.com/1.1/statuses/update.json?include_entities=true",
+		strings.NewReader("status=Hello%20Ladies%20%2B%20Gentlemen%2C%20a%20signed%20OAuth%20request%21"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err := o.Authenticate(request); err != nil {
+		t.Fatal(err)
+	}
+	auth := request.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, `OAuth oauth_consumer_key="xvz1evFS4wEEPTGEFPHBog", `) ||
+		!strings.HasSuffix(auth, `, oauth_signature="hCtSmYh%2BiHYCEqBWrE7C7hYmtUk%3D"`) {
+		t.Fatal("Unexpected authorization:", auth)
+	}
+}
+
+func TestOAuth1_RSASHA1(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := &OAuth1{ConsumerKey: "ck", SignatureMethod: OAuth1RSASHA1, PrivateKey: key, Realm: "Photos"}
+	request, _ := http.NewRequest("GET", "http://Example.com:80/photos?size=original&file=vacation.jpg", nil)
+	if err = o.Authenticate(request); err != nil {
+		t.Fatal(err)
+	}
+
+	// verify with the base string built from the header params
+	auth := request.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, `OAuth realm="Photos", `) {
+		t.Fatal("Unexpected authorization:", auth)
+	}
+	oauthParams := Vals{}
+	var signature string
+	for _, c := range parseChallenges([]string{auth}) {
+		for k, v := range c.params {
+			v, _ = url.PathUnescape(v)
+			switch k {
+			case "oauth_signature":
+				signature = v
+			case "realm":
+			default:
+				oauthParams.Add(k, v)
+			}
+		}
+	}
+	base, _ := oauth1BaseString(request, oauthParams)
+	if !strings.HasPrefix(base, "GET&http%3A%2F%2Fexample.com%2Fphotos&") {
+		t.Fatal("Unexpected base string:", base)
+	}
+	sig, _ := base64.StdEncoding.DecodeString(signature)
+	sum := sha1.Sum([]byte(base))
+	if err = rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA1, sum[:], sig); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReqSend_OAuth1(t *testing.T) {
+	var auths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("Authorization"))
+		if len(auths) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	o := &OAuth1{ConsumerKey: "ck", ConsumerSecret: "cs", SignatureMethod: OAuth1Plaintext}
+	_, err := New(srv.URL).WithAuth(o).WithAttempts(2).WithLogger(NopLogger).
+		WithForm(Vals{{"b", 1}, {"a", 2}}).Post()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// fresh nonce per attempt
+	if len(auths) != 2 || auths[0] == auths[1] || !strings.Contains(auths[1], `oauth_signature="cs%26"`) {
+		t.Fatal("Unexpected authorizations:", auths)
+	}
+}
+
+func TestOAuth1_Form(t *testing.T) {
+	r := New("http://example.com/path").WithForm(Vals{{"b", 1}, {"a", "x y"}})
+	r.Method = http.MethodPost
+	if _, err := r.buildReqRaw(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	base, err := oauth1BaseString(r.reqRaw, Vals{{"oauth_nonce", "n"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base != "POST&http%3A%2F%2Fexample.com%2Fpath&a%3Dx%2520y%26b%3D1%26oauth_nonce%3Dn" {
+		t.Fatal("Unexpected base string:", base)
+	}
+
+	// compressed form can't be signed
+	_, err = New("http://example.com/path").WithForm(Vals{{"b", 1}}).WithCompress(EncodingGzip, 0).
+		WithAuth(&OAuth1{ConsumerKey: "ck"}).WithLogger(NopLogger).Post()
+	if err == nil || !strings.Contains(err.Error(), "compressed form body can't be signed") {
+		t.Fatal("Expected compressed form err, but got:", err)
+	}
+}
@@ -0,0 +1,258 @@
+package req
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nordborn/go-errow"
+)
+
+// OAuth2 is Auth which obtains access tokens from TokenURL
+// with client credentials grant or, if there is a refresh token,
+// with refresh token grant (RFC 6749). Tokens are cached until expiry
+// and refreshed once for all concurrent requests.
+// Send retries request once with a fresh token on 401 response.
+// It's safe for concurrent use, don't copy it after the first use
+type OAuth2 struct {
+	// TokenURL is the token endpoint
+	TokenURL string
+
+	// ClientID and ClientSecret are client credentials
+	ClientID     string
+	ClientSecret string
+
+	// Scopes are requested scopes (optional)
+	Scopes []string
+
+	// RefreshToken is the initial refresh token for refresh token grant.
+	// Rotated refresh tokens from responses are used for next refreshes
+	RefreshToken string
+
+	// Params are extra params of token requests (audience, resource, ...)
+	Params Vals
+
+	// CredentialsInParams: send client credentials in form params
+	// instead of Basic Authorization header
+	CredentialsInParams bool
+
+	// Early: the token is refreshed this duration before its expiry
+	Early time.Duration
+
+	// Client is used for token requests. Default is http.DefaultClient
+	Client *http.Client
+
+	mu           sync.Mutex
+	accessToken  string
+	expiry       time.Time
+	refreshToken string
+	refreshing   chan struct{}
+}
+
+// NewOAuth2ClientCredentials generates OAuth2 with client credentials grant
+// refreshing tokens 10 seconds before expiry
+func NewOAuth2ClientCredentials(tokenURL, clientID, clientSecret string, scopes ...string) *OAuth2 {
+	return &OAuth2{
+		TokenURL:     tokenURL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Scopes:       scopes,
+		Early:        10 * time.Second,
+	}
+}
+
+// NewOAuth2RefreshToken generates OAuth2 with refresh token grant
+// refreshing tokens 10 seconds before expiry
+func NewOAuth2RefreshToken(tokenURL, clientID, clientSecret, refreshToken string) *OAuth2 {
+	return &OAuth2{
+		TokenURL:     tokenURL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RefreshToken: refreshToken,
+		Early:        10 * time.Second,
+	}
+}
+
+// Authenticate implements Auth
+func (o *OAuth2) Authenticate(request *http.Request) error {
+	token, err := o.Token(request.Context())
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Refresh implements RefreshableAuth: drops the token used by request
+// unless it was already refreshed by another request
+func (o *OAuth2) Refresh(request *http.Request, _ *http.Response) bool {
+	used := strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer ")
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.accessToken == used {
+		o.accessToken = ""
+	}
+	return true
+}
+
+// Token implements TokenSource. Only one token request is performed
+// at a time, concurrent callers wait for its result
+func (o *OAuth2) Token(ctx context.Context) (string, error) {
+	for {
+		o.mu.Lock()
+		if o.accessToken != "" && (o.expiry.IsZero() || time.Now().Add(o.Early).Before(o.expiry)) {
+			token := o.accessToken
+			o.mu.Unlock()
+			return token, nil
+		}
+		if o.refreshing == nil {
+			done := make(chan struct{})
+			o.refreshing = done
+			if o.refreshToken == "" {
+				o.refreshToken = o.RefreshToken
+			}
+			refreshToken := o.refreshToken
+			o.mu.Unlock()
+
+			t, err := o.fetch(ctx, refreshToken)
+
+			o.mu.Lock()
+			if err == nil {
+				o.setToken(t)
+			}
+			o.refreshing = nil
+			o.mu.Unlock()
+			close(done)
+			if err != nil {
+				return "", err
+			}
+			return t.AccessToken, nil
+		}
+		// wait for the token request of another caller
+		done := o.refreshing
+		o.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return "", errow.Wrap(ctx.Err(), "waiting for token")
+		}
+	}
+}
+
+// oauth2Token is the token endpoint response
+type oauth2Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// expiry returns expiry time (zero if unknown)
+func (t oauth2Token) expiry() time.Time {
+	if t.ExpiresIn <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(t.ExpiresIn) * time.Second)
+}
+
+// setToken stores t, the caller holds mu
+func (o *OAuth2) setToken(t oauth2Token) {
+	o.accessToken, o.expiry = t.AccessToken, t.expiry()
+	if t.RefreshToken != "" {
+		o.refreshToken = t.RefreshToken
+	}
+}
+
+// OAuth2Error is an error response of OAuth2 endpoint (RFC 6749 5.2)
+type OAuth2Error struct {
+	Status      int    `json:"-"`
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *OAuth2Error) Error() string {
+	msg := fmt.Sprintf("oauth2 error '%v' (status code %v)", e.Code, e.Status)
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	return msg
+}
+
+// fetch requests new token with refresh token grant if refreshToken
+// is not empty, otherwise with client credentials grant
+func (o *OAuth2) fetch(ctx context.Context, refreshToken string) (oauth2Token, error) {
+	form := Vals{{"grant_type", "client_credentials"}}
+	if refreshToken != "" {
+		form = Vals{{"grant_type", "refresh_token"}, {"refresh_token", refreshToken}}
+	}
+	if len(o.Scopes) > 0 {
+		form.Add("scope", strings.Join(o.Scopes, " "))
+	}
+	t, err := o.tokenRequest(ctx, form.Extend(o.Params))
+	if err != nil {
+		return t, errow.Wrap(err, "token request failed")
+	}
+	return t, nil
+}
+
+// tokenRequest requests token from TokenURL with form
+func (o *OAuth2) tokenRequest(ctx context.Context, form Vals) (oauth2Token, error) {
+	var t oauth2Token
+	if err := o.post(ctx, o.TokenURL, form, &t); err != nil {
+		return t, err
+	}
+	if t.AccessToken == "" {
+		return t, errow.New("no access_token in token response")
+	}
+	if t.TokenType != "" && !strings.EqualFold(t.TokenType, "bearer") {
+		return t, errow.Newf("unsupported token type '%v'", t.TokenType)
+	}
+	return t, nil
+}
+
+// post sends form with client credentials to OAuth2 endpoint
+// and decodes JSON response to out.
+// Error responses are returned as *OAuth2Error (not wrapped)
+func (o *OAuth2) post(ctx context.Context, endpoint string, form Vals, out any) error {
+	r := New(endpoint).WithContext(ctx).WithHeaders(Vals{
+		{"Content-Type", "application/x-www-form-urlencoded"},
+		{"Accept", "application/json"},
+	})
+	if o.Client != nil {
+		r.Client = o.Client
+	}
+	if o.CredentialsInParams || o.ClientSecret == "" {
+		// public clients send only client_id
+		form = form.Extend(Vals{{"client_id", o.ClientID}})
+		if o.ClientSecret != "" {
+			form.Add("client_secret", o.ClientSecret)
+		}
+	} else {
+		// RFC 6749 2.3.1: credentials are form-encoded before Basic encoding
+		r.Auth = BasicAuth{url.QueryEscape(o.ClientID), url.QueryEscape(o.ClientSecret)}
+	}
+	r.Form = form
+	// error responses (4xx) are decoded, "error" marker is expected there
+	r.RetryOnStatusCodes = [][2]int{{500, 600}}
+	r.RetryOnTextMarkers = nil
+
+	resp, err := r.Post()
+	if err != nil {
+		return errow.Wrap(err)
+	}
+	if resp.RespRaw.StatusCode >= 400 {
+		oe := &OAuth2Error{Status: resp.RespRaw.StatusCode}
+		if resp.JSON(oe) != nil || oe.Code == "" {
+			return errow.Newf("unexpected response with status code '%v'", oe.Status)
+		}
+		return oe
+	}
+	if err = resp.JSON(out); err != nil {
+		return errow.Wrap(err, "bad response")
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package req
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nordborn/go-errow"
+)
+
+// AuthCodeFlow is user-interactive authorization code grant with PKCE
+// (RFC 7636) and loopback redirect (RFC 8252) for CLI tools,
+// see OAuth2.LoginAuthCode
+type AuthCodeFlow struct {
+	// AuthURL is the authorization endpoint
+	AuthURL string
+
+	// Open shows authorization URL to the user
+	// (opens browser or prints it)
+	Open func(authURL string) error
+
+	// Addr is the loopback listener address. Default is "127.0.0.1:0"
+	// (random port, the authorization server must allow any port)
+	Addr string
+
+	// RedirectPath is the path of redirect URI. Default is "/callback"
+	RedirectPath string
+
+	// Params are extra params of authorization URL (prompt, audience, ...),
+	// they can't redefine params of the protocol (state, redirect_uri, ...)
+	Params Vals
+}
+
+// authCodeParams are params of authorization URL set by LoginAuthCode
+var authCodeParams = []string{
+	"response_type", "client_id", "redirect_uri", "state", "code_challenge", "code_challenge_method",
+}
+
+// LoginAuthCode performs authorization code grant with PKCE:
+// it starts loopback listener, shows authorization URL with flow.Open,
+// waits for redirect with the code and exchanges the code for tokens.
+// Then o refreshes tokens with the received refresh token
+func (o *OAuth2) LoginAuthCode(ctx context.Context, flow AuthCodeFlow) error {
+	if flow.Open == nil {
+		return errow.New("no Open func of auth code flow")
+	}
+	for _, v := range flow.Params {
+		if containsFold(authCodeParams, v.K) {
+			return errow.Newf("param '%v' is reserved by auth code flow", v.K)
+		}
+	}
+	addr := flow.Addr
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	path := flow.RedirectPath
+	if path == "" {
+		path = "/callback"
+	}
+	verifier := randomString(32)
+	challenge := sha256.Sum256([]byte(verifier))
+	state := randomString(16)
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errow.Wrap(err, "can't start loopback listener")
+	}
+	redirectURI := "http://" + ln.Addr().String() + path
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		res := result{code: q.Get("code")}
+		switch {
+		case q.Get("state") != state:
+			res.err = errow.New("bad state in redirect")
+		case q.Get("error") != "":
+			res.err = &OAuth2Error{Code: q.Get("error"), Description: q.Get("error_description")}
+		case res.code == "":
+			res.err = errow.New("no code in redirect")
+		}
+		if res.err != nil {
+			http.Error(w, "Authorization failed, you can close this window.", http.StatusBadRequest)
+		} else {
+			fmt.Fprint(w, "Authorization succeeded, you can close this window.")
+		}
+		select {
+		case results <- res:
+		default:
+		}
+	})
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(ln)
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	authURL, err := url.Parse(flow.AuthURL)
+	if err != nil {
+		return errow.Wrap(err, "bad auth url")
+	}
+	q := authURL.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", o.ClientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("state", state)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	if len(o.Scopes) > 0 {
+		q.Set("scope", strings.Join(o.Scopes, " "))
+	}
+	for _, v := range flow.Params {
+		q.Set(v.K, fmt.Sprint(v.V))
+	}
+	authURL.RawQuery = q.Encode()
+	if err = flow.Open(authURL.String()); err != nil {
+		return errow.Wrap(err, "can't open auth url")
+	}
+
+	var res result
+	select {
+	case res = <-results:
+	case <-ctx.Done():
+		return errow.Wrap(ctx.Err(), "waiting for redirect")
+	}
+	if res.err != nil {
+		return errow.Wrap(res.err, "authorization failed")
+	}
+
+	t, err := o.tokenRequest(ctx, Vals{
+		{"grant_type", "authorization_code"},
+		{"code", res.code},
+		{"redirect_uri", redirectURI},
+		{"code_verifier", verifier},
+	})
+	if err != nil {
+		return errow.Wrap(err, "code exchange failed")
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.setToken(t)
+	return nil
+}
+
+// DeviceFlow is device authorization grant (RFC 8628)
+// for devices without browser, see OAuth2.LoginDevice
+type DeviceFlow struct {
+	// DeviceAuthURL is the device authorization endpoint
+	DeviceAuthURL string
+
+	// Prompt shows verification URI and user code to the user
+	Prompt func(code DeviceCode) error
+
+	// intervalUnit is the unit of polling intervals
+	// (default is second, reduced in tests)
+	intervalUnit time.Duration
+}
+
+// DeviceCode is the device authorization response
+type DeviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// LoginDevice performs device authorization grant: it requests
+// device code, shows it with flow.Prompt and polls the token endpoint
+// honoring interval and slow_down until the user authorizes the device.
+// Then o refreshes tokens with the received refresh token
+func (o *OAuth2) LoginDevice(ctx context.Context, flow DeviceFlow) error {
+	if flow.Prompt == nil {
+		return errow.New("no Prompt func of device flow")
+	}
+	unit := flow.intervalUnit
+	if unit == 0 {
+		unit = time.Second
+	}
+	form := Vals{}
+	if len(o.Scopes) > 0 {
+		form.Add("scope", strings.Join(o.Scopes, " "))
+	}
+	var code DeviceCode
+	if err := o.post(ctx, flow.DeviceAuthURL, form.Extend(o.Params), &code); err != nil {
+		return errow.Wrap(err, "device authorization failed")
+	}
+	if code.DeviceCode == "" {
+		return errow.New("no device_code in device authorization response")
+	}
+	if err := flow.Prompt(code); err != nil {
+		return errow.Wrap(err, "can't prompt user")
+	}
+
+	if code.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(code.ExpiresIn)*unit)
+		defer cancel()
+	}
+	interval := time.Duration(code.Interval) * unit
+	if interval <= 0 {
+		interval = 5 * unit
+	}
+	form = Vals{
+		{"grant_type", "urn:ietf:params:oauth:grant-type:device_code"},
+		{"device_code", code.DeviceCode},
+	}
+	for {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return errow.Wrap(ctx.Err(), "waiting for device authorization")
+		}
+		t, err := o.tokenRequest(ctx, form)
+		if err == nil {
+			o.mu.Lock()
+			defer o.mu.Unlock()
+			o.setToken(t)
+			return nil
+		}
+		var oe *OAuth2Error
+		ok := errors.As(err, &oe)
+		switch {
+		case ok && oe.Code == "authorization_pending":
+		case ok && oe.Code == "slow_down":
+			interval += 5 * unit
+		default:
+			return errow.Wrap(err, "device token request failed")
+		}
+	}
+}
+
+// randomString returns n random bytes as unpadded base64url
+func randomString(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,140 @@
+package req
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestOAuth2_LoginAuthCode(t *testing.T) {
+	var challenge string
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("code") != "abc" ||
+			r.PostForm.Get("client_id") != "cli" ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"invalid_grant"}`)
+			return
+		}
+		fmt.Fprint(w, `{"access_token":"at","token_type":"bearer","refresh_token":"rt","expires_in":60}`)
+	}))
+	defer tokenSrv.Close()
+
+	o := &OAuth2{TokenURL: tokenSrv.URL, ClientID: "cli", Scopes: []string{"openid"}}
+	redirected := make(chan string, 1)
+	open := func(authURL string) error {
+		// the user authorizes and the browser is redirected
+		u, _ := url.Parse(authURL)
+		q := u.Query()
+		if q.Get("code_challenge_method") != "S256" || q.Get("scope") != "openid" ||
+			q.Get("prompt") != "consent" {
+			return fmt.Errorf("bad auth url: %v", authURL)
+		}
+		challenge = q.Get("code_challenge")
+		go func() {
+			resp, err := New(q.Get("redirect_uri")).WithParams(Vals{
+				{"code", "abc"}, {"state", q.Get("state")},
+			}).Get()
+			if err != nil {
+				redirected <- err.Error()
+				return
+			}
+			redirected <- resp.Text()
+		}()
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := o.LoginAuthCode(ctx, AuthCodeFlow{
+		AuthURL: "https://auth.example.com/authorize",
+		Open:    open,
+		Params:  Vals{{"prompt", "consent"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _ := o.Token(ctx)
+	if token != "at" || o.refreshToken != "rt" {
+		t.Fatal("Unexpected result:", token, o.refreshToken)
+	}
+	if text := <-redirected; text != "Authorization succeeded, you can close this window." {
+		t.Fatal("Unexpected redirect response:", text)
+	}
+}
+
+func TestOAuth2_LoginAuthCodeBadFlow(t *testing.T) {
+	o := &OAuth2{ClientID: "cli"}
+	flows := []AuthCodeFlow{
+		{AuthURL: "https://auth.example.com/authorize"},
+		{AuthURL: "https://auth.example.com/authorize", Open: func(string) error { return nil },
+			Params: Vals{{"State", "mine"}}},
+	}
+	for _, flow := range flows {
+		if err := o.LoginAuthCode(context.Background(), flow); err == nil {
+			t.Fatal("Expected err, but got nil")
+		}
+	}
+	if err := o.LoginDevice(context.Background(), DeviceFlow{}); err == nil {
+		t.Fatal("Expected err, but got nil")
+	}
+}
+
+func TestOAuth2_LoginDevice(t *testing.T) {
+	var polls []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.URL.Path == "/device" {
+			fmt.Fprint(w, `{"device_code":"dc","user_code":"ABCD-EFGH",`+
+				`"verification_uri":"https://example.com/device","expires_in":1000,"interval":10}`)
+			return
+		}
+		if r.PostForm.Get("device_code") != "dc" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"invalid_grant"}`)
+			return
+		}
+		polls = append(polls, time.Now())
+		switch len(polls) {
+		case 1:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"authorization_pending"}`)
+		case 2:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"slow_down"}`)
+		default:
+			fmt.Fprint(w, `{"access_token":"at","token_type":"Bearer"}`)
+		}
+	}))
+	defer srv.Close()
+
+	o := &OAuth2{TokenURL: srv.URL + "/token", ClientID: "cli"}
+	var userCode string
+	err := o.LoginDevice(context.Background(), DeviceFlow{
+		DeviceAuthURL: srv.URL + "/device",
+		Prompt: func(code DeviceCode) error {
+			userCode = code.UserCode
+			return nil
+		},
+		intervalUnit: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _ := o.Token(context.Background())
+	if token != "at" || userCode != "ABCD-EFGH" || len(polls) != 3 {
+		t.Fatal("Unexpected result:", token, userCode, len(polls))
+	}
+	// slow_down increases interval by 5
+	if polls[2].Sub(polls[1]) < 15*time.Millisecond {
+		t.Fatal("Unexpected interval after slow_down:", polls[2].Sub(polls[1]))
+	}
+
+}
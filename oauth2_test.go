@@ -0,0 +1,121 @@
+package req
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTokenServer returns token server issuing "token<N>" access tokens
+// and counter of token requests
+func newTokenServer(t *testing.T) (*httptest.Server, *int32) {
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		r.ParseForm()
+		grant := r.PostForm.Get("grant_type")
+		if id != "client" || secret != "secret" ||
+			grant == "refresh_token" && r.PostForm.Get("refresh_token") != "rt" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"invalid_client"}`)
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+		num := atomic.AddInt32(&n, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token%v","token_type":"Bearer","expires_in":3600,"refresh_token":"rt","scope":"%v"}`,
+			num, r.PostForm.Get("scope"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &n
+}
+
+func TestOAuth2_ClientCredentialsConcurrent(t *testing.T) {
+	tokenSrv, fetched := newTokenServer(t)
+	o := NewOAuth2ClientCredentials(tokenSrv.URL, "client", "secret", "read", "write")
+
+	var wg sync.WaitGroup
+	tokens := make([]string, 10)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tokens[i], _ = o.Token(context.Background())
+		}(i)
+	}
+	wg.Wait()
+	for _, token := range tokens {
+		if token != "token1" {
+			t.Fatal("Unexpected tokens:", tokens)
+		}
+	}
+	if *fetched != 1 {
+		t.Fatal("Unexpected number of token requests:", *fetched)
+	}
+}
+
+func TestOAuth2_RefreshToken(t *testing.T) {
+	tokenSrv, _ := newTokenServer(t)
+	o := NewOAuth2RefreshToken(tokenSrv.URL, "client", "secret", "rt")
+	token, err := o.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "token1" {
+		t.Fatal("Unexpected token:", token)
+	}
+
+	o = NewOAuth2RefreshToken(tokenSrv.URL, "client", "secret", "bad")
+	if _, err = o.Token(context.Background()); err == nil {
+		t.Fatal("Expected err, but got nil")
+	}
+}
+
+func TestReqSend_OAuth2Retry401(t *testing.T) {
+	tokenSrv, fetched := newTokenServer(t)
+	var auths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") != "Bearer token2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+
+	o := NewOAuth2ClientCredentials(tokenSrv.URL, "client", "secret")
+	m := &testMetrics{}
+	resp, err := New(srv.URL).WithAuth(o).WithMetrics(m).WithBody("data").Post()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(m.calls[1:3]) != "[attempt 1 401 auth attempt 2 200 ]" {
+		t.Fatal("Unexpected metrics:", m.calls)
+	}
+	// the refresh is a separate attempt which doesn't consume Attempts
+	if resp.Text() != "ok" || len(resp.History) != 2 || resp.History[0].Status != 401 || *fetched != 2 {
+		t.Fatal("Unexpected result:", resp.Text(), resp.History, *fetched)
+	}
+	if fmt.Sprint(auths) != "[Bearer token1 Bearer token2]" {
+		t.Fatal("Unexpected auths:", auths)
+	}
+
+	// only once
+	_, err = New(srv.URL).WithAuth(o).WithLogger(NopLogger).Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Refresh(&http.Request{Header: http.Header{"Authorization": {"Bearer token2"}}}, nil)
+	if _, err = New(srv.URL).WithAuth(o).WithLogger(NopLogger).Get(); err == nil {
+		t.Fatal("Expected err, but got nil")
+	}
+	if *fetched != 4 {
+		t.Fatal("Unexpected number of token requests:", *fetched)
+	}
+}
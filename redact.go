@@ -0,0 +1,299 @@
+package req
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// RedactMask replaces redacted values by default
+const RedactMask = "[REDACTED]"
+
+// Redactor hides secrets in logs, error messages, attempt history
+// and debug dumps produced by Req.
+// Zero Redactor redacts nothing
+type Redactor struct {
+	// Headers: names of sensitive headers (case-insensitive)
+	Headers []string
+
+	// Params: names of sensitive query and form parameters
+	// (case-insensitive), also used for URL userinfo password
+	Params []string
+
+	// JSONPaths: dotted paths of sensitive fields of JSON bodies
+	// from the root object: "data.token".
+	// "*" matches any single key, "**" matches any number of keys
+	// ("**.password" is a password at any depth).
+	// Arrays are transparent: "items.secret" matches secret of each item
+	JSONPaths []string
+
+	// Patterns are applied to all texts (bodies, URLs, errors).
+	// If a pattern has groups, only the groups are replaced,
+	// otherwise the whole match is replaced
+	Patterns []*regexp.Regexp
+
+	// Mask replaces sensitive values. Default is RedactMask
+	Mask string
+}
+
+// DefaultRedactor is used if Req.Redactor is nil.
+// It redacts common auth headers, parameters, JSON fields
+// and bearer tokens
+var DefaultRedactor = &Redactor{
+	Headers: []string{
+		"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie",
+		"X-Api-Key", "X-Auth-Token", "X-Amz-Security-Token",
+	},
+	Params: []string{
+		"api_key", "apikey", "key", "token", "access_token", "refresh_token",
+		"id_token", "password", "secret", "client_secret", "signature", "sig",
+		"X-Amz-Signature", "X-Amz-Security-Token", "X-Amz-Credential",
+	},
+	JSONPaths: []string{
+		"**.access_token", "**.refresh_token", "**.id_token",
+		"**.password", "**.client_secret", "**.secret", "**.token",
+	},
+	Patterns: []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bbearer\s+([a-z0-9._~+/=-]+)`),
+	},
+}
+
+// mask returns Mask or RedactMask
+func (rd *Redactor) mask() string {
+	if rd.Mask != "" {
+		return rd.Mask
+	}
+	return RedactMask
+}
+
+// isHeader checks whether the header is sensitive
+func (rd *Redactor) isHeader(name string) bool {
+	return containsFold(rd.Headers, name)
+}
+
+// isParam checks whether the parameter is sensitive
+func (rd *Redactor) isParam(name string) bool {
+	return containsFold(rd.Params, name)
+}
+
+// URL redacts sensitive query parameters and userinfo password
+// of the raw URL
+func (rd *Redactor) URL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rd.Text(rawURL)
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = rd.query(u.RawQuery)
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		// keep the mask unescaped for readability
+		u.User = url.UserPassword(u.User.Username(), rd.mask())
+		escaped := strings.TrimPrefix(url.UserPassword("", rd.mask()).String(), ":")
+		return rd.Text(strings.Replace(u.String(), ":"+escaped+"@", ":"+rd.mask()+"@", 1))
+	}
+	return rd.Text(u.String())
+}
+
+// query redacts sensitive parameters of urlencoded query or form
+// keeping order and encoding of other parameters
+func (rd *Redactor) query(query string) string {
+	if len(rd.Params) == 0 {
+		return query
+	}
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		k, _, hasValue := strings.Cut(param, "=")
+		name, err := url.QueryUnescape(k)
+		if err != nil {
+			name = k
+		}
+		if hasValue && rd.isParam(name) {
+			params[i] = k + "=" + rd.mask()
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// Header returns copy of the header with redacted sensitive values
+func (rd *Redactor) Header(header http.Header) http.Header {
+	redacted := make(http.Header, len(header))
+	for k, values := range header {
+		redacted[k] = make([]string, len(values))
+		for i, v := range values {
+			if rd.isHeader(k) {
+				redacted[k][i] = rd.mask()
+			} else {
+				redacted[k][i] = rd.Text(v)
+			}
+		}
+	}
+	return redacted
+}
+
+// Body redacts JSON fields (for JSON content type or valid JSON object),
+// form parameters (for urlencoded form content type) and Patterns
+// of the body
+func (rd *Redactor) Body(contentType string, body []byte) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		body = []byte(rd.query(string(body)))
+	case len(rd.JSONPaths) > 0 &&
+		(strings.Contains(mediaType, "json") || (hasSeqSig(string(body)) && json.Valid(body))):
+		var vals Vals
+		if err := vals.UnmarshalJSON(body); err != nil {
+			// truncated or broken JSON: redact string fields by names
+			body = rd.jsonFieldsByName(body)
+			break
+		}
+		if b, err := rd.jsonVals(vals, nil).MarshalJSON(); err == nil {
+			body = b
+		}
+	}
+	return []byte(rd.Text(string(body)))
+}
+
+// jsonFieldsByName redacts string values of fields named as the last
+// segments of JSONPaths in JSON text which can't be parsed
+func (rd *Redactor) jsonFieldsByName(body []byte) []byte {
+	for _, p := range rd.JSONPaths {
+		name := p[strings.LastIndex(p, ".")+1:]
+		if name == "*" || name == "**" {
+			continue
+		}
+		re := regexp.MustCompile(`(?i)"` + regexp.QuoteMeta(name) + `"\s*:\s*"((?:[^"\\]|\\.)*)"?`)
+		body = []byte(rd.replacePattern(re, string(body)))
+	}
+	return body
+}
+
+// jsonVals returns copy of vals with redacted values of JSONPaths
+func (rd *Redactor) jsonVals(vals Vals, path []string) Vals {
+	redacted := make(Vals, len(vals))
+	for i, v := range vals {
+		fieldPath := append(path[:len(path):len(path)], v.K)
+		if rd.isJSONPath(fieldPath) {
+			redacted[i] = val{v.K, rd.mask()}
+		} else {
+			redacted[i] = val{v.K, rd.jsonValue(v.V, fieldPath)}
+		}
+	}
+	return redacted
+}
+
+// jsonValue redacts nested values of JSON value at the path
+func (rd *Redactor) jsonValue(value any, path []string) any {
+	switch value := value.(type) {
+	case Vals:
+		return rd.jsonVals(value, path)
+	case []any:
+		items := make([]any, len(value))
+		for i, item := range value {
+			items[i] = rd.jsonValue(item, path)
+		}
+		return items
+	}
+	return value
+}
+
+// isJSONPath checks whether the path matches any of JSONPaths
+func (rd *Redactor) isJSONPath(path []string) bool {
+	for _, p := range rd.JSONPaths {
+		if matchJSONPath(strings.Split(p, "."), path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchJSONPath matches path by pattern segments with "*" and "**"
+func matchJSONPath(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchJSONPath(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if pattern[0] != "*" && !strings.EqualFold(pattern[0], path[0]) {
+		return false
+	}
+	return matchJSONPath(pattern[1:], path[1:])
+}
+
+// Text applies Patterns to s and replaces raw URLs found in s
+// with their redacted versions
+func (rd *Redactor) Text(s string, rawURLs ...string) string {
+	for _, rawURL := range rawURLs {
+		if rawURL != "" {
+			s = strings.ReplaceAll(s, rawURL, rd.URL(rawURL))
+		}
+	}
+	for _, re := range rd.Patterns {
+		s = rd.replacePattern(re, s)
+	}
+	return s
+}
+
+// replacePattern replaces matches (or groups of matches) of re in s
+func (rd *Redactor) replacePattern(re *regexp.Regexp, s string) string {
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+	var (
+		b    strings.Builder
+		last int
+	)
+	for _, m := range matches {
+		// whole match or groups
+		spans := [][2]int{{m[0], m[1]}}
+		if len(m) > 2 {
+			spans = spans[:0]
+			for g := 2; g+1 < len(m); g += 2 {
+				if m[g] >= 0 {
+					spans = append(spans, [2]int{m[g], m[g+1]})
+				}
+			}
+		}
+		for _, span := range spans {
+			if span[0] < last {
+				continue
+			}
+			b.WriteString(s[last:span[0]])
+			b.WriteString(rd.mask())
+			last = span[1]
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// redactor returns Req.Redactor or DefaultRedactor
+func (r *Req) redactor() *Redactor {
+	if r.Redactor != nil {
+		return r.Redactor
+	}
+	return DefaultRedactor
+}
+
+// containsFold checks whether the list contains s (case-insensitive)
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
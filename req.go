@@ -8,8 +8,9 @@
 // - Middleware (slice of functions executing before each
 //               request attempt)
 // - Vals - ordered HTTP parameters (instead of url.Values which is a map)
-// - for now, it doesn't support sessions (you should pass cookies directly)
-//   and was developed mostly as a client for REST APIs
+// - Session - settings (Client, Logger, Auth, ...) shared by requests
+//   (cookies are kept by Client.Jar if it's set); it was developed
+//   mostly as a client for REST APIs
 //
 // ---
 // Example1: Path, Params, Data, resp.JSON
@@ -43,6 +44,8 @@
 package req
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -51,7 +54,6 @@ import (
 	"time"
 
 	"github.com/nordborn/go-errow"
-	"github.com/nordborn/golog"
 )
 
 var (
@@ -75,35 +77,87 @@ type Req struct {
 	// URL is a basic URL ("http://example.com")
 	URL string
 
-	// Path is URL path after domain name ("/test/me/") without get parameters
+	// Path is URL path after domain name ("/test/me/") without get parameters.
+	// It can be a template with {name} placeholders
+	// ("/users/{id}/orders/{orderID}") filled from PathParams.
+	// The template is kept as is, so it's suitable for metrics and logs labels
 	Path string
 
+	// PathParams: values of Path placeholders as Vals:
+	// ({{"id", 1}, {"orderID", "a/b"}} => /users/1/orders/a%2Fb).
+	// Values are path-escaped, each placeholder must have a value
+	PathParams Vals
+
 	// Params: get parameters as Vals:
 	// ({{"par1", "val1"}, {"par2", "val2"}} => ?par1=val1&par2=val2)
 	Params Vals
 
-	// Headers: HTTP headers as Vals: req.Vals{{"Content-Type", "application/json"}}
+	// ParamsStyle defines how slices, maps and nested Vals
+	// in Params are encoded (a[]=1&a[]=2, a=1,2, etc.).
+	// Default is StylePlain (values are encoded with fmt.Sprint)
+	ParamsStyle EncodeStyle
+
+	// Headers: HTTP headers as Vals: req.Vals{{"Content-Type", "application/json"}}.
+	// Slice values are sent as multiple values of the header:
+	// req.Vals{{"Accept", []string{"text/html", "application/json"}}}.
+	// "Host" header overrides host of the request.
+	// Note, that net/http writes headers sorted by name,
+	// only values of the same header keep their order
 	Headers Vals
 
+	// HeadersAdd: if true, then repeated keys in Headers are added
+	// to the values of the header, otherwise the last one wins
+	HeadersAdd bool
+
 	// ProxyURL should be string in format "http://user:name@ip:port"
 	ProxyURL string
 
 	// POST/PATCH/PUT parameters as urlencoded Vals
+	// (with "application/x-www-form-urlencoded" Content-Type header
+	// if Headers don't set it)
 	Form Vals
 
+	// FormStyle is like ParamsStyle, but for Form
+	FormStyle EncodeStyle
+
 	// Body is a HTTP request body that contains urlencoded string
 	// (useful for JSON data or encoded POST/PUT/PATCH parameters).
 	// If provided, then Body will be used in request instead of Form
 	Body string
 
+	// BodyStream returns a reader of streamed request body
+	// (large files, multipart.Writer over io.Pipe, etc.).
+	// It's called before each attempt to get a fresh reader for retries.
+	// If provided, then BodyStream will be used instead of Body and Form
+	BodyStream func() (io.Reader, error)
+
+	// Compress is the encoding to compress request body with:
+	// EncodingGzip, EncodingDeflate or EncodingZstd.
+	// Content-Encoding header will be set accordingly.
+	// Default is "" (no compression)
+	Compress string
+
+	// CompressMinBytes: bodies smaller than this size
+	// are sent without compression
+	CompressMinBytes int
+
 	// Middleware is the slice of functions to be processed
 	// before each request and each retry attempt;
 	// they can modify Req fields.
 	// Useful for example, for Headers and ProxyURL if they should be
 	// updated before each retry attempt
-	// (see TestReqGetJSON_MiddlewareVals)
+	// (see TestReqGetJSON_MiddlewareVals).
+	// Use Interceptors to access the built request, response and errors
 	Middleware []func()
 
+	// Interceptors wrap each attempt after Middleware
+	// (the first one is the outermost), see Interceptor
+	Interceptors []Interceptor
+
+	// ResponseHooks are called in order after each attempt response
+	// before retry evaluation, see ResponseHook
+	ResponseHooks []ResponseHook
+
 	// RetryOnTextMarkers will trigger retry attempt if found any of
 	// text markers from the slice
 	// Default is []string{"error", "Error"}
@@ -127,15 +181,65 @@ type Req struct {
 	// Each cookie will be added to the request
 	Cookies []*http.Cookie
 
-	reqRaw *http.Request
-	Client *http.Client
+	// Logger receives structured log records of Send.
+	// Default is nil (DefaultLogger is used), NopLogger disables logging
+	Logger Logger
+
+	// Redactor hides secrets in logs, error messages, attempt history
+	// and debug dumps. Default is nil (DefaultRedactor is used),
+	// &Redactor{} disables redaction
+	Redactor *Redactor
+
+	// Debug dumps requests and responses of each attempt
+	// (see NewDebug). Default is nil (no dumps)
+	Debug *Debug
+
+	// Metrics receives measurements of requests and attempts.
+	// Default is nil (no metrics)
+	Metrics Metrics
+
+	// RequestIDFunc generates request (correlation) ID (UUIDv4, ULID or custom)
+	// sent in RequestIDHeader of all attempts. ID from Headers
+	// or from Context (see ContextWithRequestID) is used if present.
+	// The ID is added to logs and errors and recorded in Resp.RequestID.
+	// Default is nil (no ID unless it's present)
+	RequestIDFunc RequestIDFunc
+
+	// RequestIDHeader is the header of request ID.
+	// Default is "" (DefaultRequestIDHeader is used)
+	RequestIDHeader string
+
+	// AttemptHeader is the header with attempt number (starting from 1).
+	// Default is "" (no header)
+	AttemptHeader string
+
+	// Tracer instruments requests and attempts with spans.
+	// Default is nil (no tracing)
+	Tracer Tracer
+
+	// Context of the request: cancellation stops the request
+	// and retries, it's also passed to Logger and Tracer.
+	// Default is nil (context.Background() is used)
+	Context context.Context
+
+	// Auth authenticates each attempt (BasicAuth, BearerAuth, APIKeyAuth
+	// or custom). Auth headers are removed on redirects to a different host.
+	// Default is nil (no auth)
+	Auth Auth
+
+	reqRaw      *http.Request
+	authHeaders []string
+	Client      *http.Client
+
+	// proxy transport of the current Send (a copy of Client's one)
+	proxyTransport    *http.Transport
+	proxyTransportURL string
 }
 
 // New generates Req with default arguments.
-// Note, that using default global `http.DefaultClient` is not suitable
-// for different timeouts and proxies (client's transport-level settings).
-// In this case set custom client.
-// `Client.Transport` is expected to be `*http.Transport` to manage proxies.
+// Client isn't modified, Timeout and ProxyURL are applied to its copy
+// for each request, so the Client can be shared (see Session).
+// `Client.Transport` is expected to be nil or `*http.Transport` to manage proxies.
 func New(url string) *Req {
 	req := Req{
 		URL:                url,
@@ -150,6 +254,25 @@ func New(url string) *Req {
 	return &req
 }
 
+// context returns Req.Context or context.Background()
+func (r *Req) context() context.Context {
+	if r.Context != nil {
+		return r.Context
+	}
+	return context.Background()
+}
+
+// bodyReader returns request body reader from BodyStream, Form or Body
+func (r *Req) bodyReader() (io.Reader, error) {
+	if r.BodyStream != nil {
+		return r.BodyStream()
+	}
+	if r.Form != nil {
+		return strings.NewReader(r.Form.URLEncodeStyle(r.FormStyle)), nil
+	}
+	return strings.NewReader(r.Body), nil
+}
+
 // ReqRaw provides read access to underlying http.Request
 // _after_ http request.
 // You can't set reqRaw directly because it have to be
@@ -160,141 +283,308 @@ func (r *Req) ReqRaw() *http.Request {
 
 // Send provides HTTP request with given arguments.
 // If postParams passed, then usual PostForm method wil be used
-// Req builds client and reqRaw once and
-// then at each attempt only if len(Middleware) > 0
+// Req builds reqRaw at each attempt
 func (r *Req) Send() (*Resp, error) {
 	var (
 		respRaw *http.Response
 		content []byte
-		myResp  Resp
+		history []AttemptInfo
 		err     error
-		attempt int
 		success bool
 		reason  string
 		fullURL string
+		prev    *Resp
+		prevErr error
 	)
 
-	// closure to call from attempt
-	// suitable to apply middleware between attempts
-	buildReqRaw := func() error {
-		r.Client.Timeout = r.Timeout
-
-		if r.ProxyURL != "" {
-			proxyURL, err := url.Parse(r.ProxyURL)
-			if err != nil {
-				return errow.Wrap(err, "bad proxy url")
-			} else {
-				t := r.Client.Transport.(*http.Transport)
-				t.Proxy = http.ProxyURL(proxyURL)
-			}
-		}
+	ctx := r.context()
+	logger := r.logger()
+	rd := r.redactor()
+	urlTmpl := redactURLTemplate(rd, urlTemplate(r.URL, r.Path))
+	started := time.Now()
 
-		fullURL, err = buildFullURL(r.URL, r.Path, r.Params)
-		if err != nil {
-			return errow.Wrap(err, "bad full url")
+	// stable for all attempts
+	requestID := r.requestID(ctx)
+	if requestID != "" {
+		ctx = ContextWithRequestID(ctx, requestID)
+	}
+	// logFields returns common fields of log records with more fields
+	logFields := func(more ...val) Vals {
+		fields := Vals{{"method", r.Method}, {"url", urlTmpl}}
+		if requestID != "" {
+			fields = append(fields, val{"request_id", requestID})
 		}
+		return append(fields, more...)
+	}
 
-		// set reqBody from Data if provided or directly from Body
-		var reqBody string
-		if r.Form != nil {
-			reqBody = r.Form.URLEncode()
-		} else {
-			reqBody = r.Body
+	labels := r.metricLabels()
+	if r.Metrics != nil {
+		r.Metrics.RequestStarted(labels)
+	}
+	var span RequestSpan
+	if r.Tracer != nil {
+		ctx, span = r.Tracer.StartRequest(ctx, r)
+	}
+	// finish reports the result to Metrics and Tracer
+	finish := func(resp *Resp, err error) (*Resp, error) {
+		r.setProxyTransport(nil, "")
+		if span != nil {
+			span.End(resp, err)
 		}
-
-		r.reqRaw, err = http.NewRequest(r.Method, fullURL, strings.NewReader(reqBody))
-		if err != nil {
-			return errow.Wrap(err, "bad req raw")
+		if r.Metrics != nil {
+			status := 0
+			if respRaw != nil {
+				status = respRaw.StatusCode
+			}
+			r.Metrics.RequestDone(labels, status, len(history), time.Since(started), err)
 		}
-		setCookies(r.reqRaw, r.Cookies)
-		setHeaders(r.reqRaw, r.Headers)
-		return nil
+		return resp, err
 	}
 
-	for attempt = 1; attempt <= r.Attempts; attempt++ {
-		shouldRetry := false
-
+	// auth retries (see RefreshableAuth) extend the limit of attempts
+	limit, authRetry := r.Attempts, false
+	for attempt := 1; attempt <= limit; attempt++ {
 		for _, f := range r.Middleware {
 			f()
 		}
 
-		// first time or after middleware
-		if r.reqRaw == nil || len(r.Middleware) > 0 {
-			if buildReqRaw() != nil {
-				return nil, err // already wrapped err
-			}
+		// at each attempt: fields could be changed by middleware
+		// or since the previous Send, and the body can be read only once
+		fullURL, err = r.prepareReqRaw(ctx, requestID, attempt)
+		if err != nil {
+			return finish(nil, err) // already wrapped err
 		}
 
-		// applied closure to close resp Body in the loop even if err occur
-		func() {
-			golog.Tracef("do request: %v %v\n", r.Method, fullURL)
-			respRaw, err = r.Client.Do(r.reqRaw)
-			if err != nil {
-				shouldRetry = true
-				errStr := err.Error()
-				if strings.Contains(errStr, fullURL) {
-					golog.Warningf("att #%v: resp err: %v. Retry\n", attempt, errStr)
-				} else {
-					golog.Warningf("att #%v: resp err: %v: %v. Retry\n", attempt, fullURL, errStr)
-				}
-				reason = errStr
-				return
-			}
-			defer respRaw.Body.Close()
-			content, err = io.ReadAll(respRaw.Body)
-			if err != nil {
-				shouldRetry = true
-				golog.Warningf("att #%v: resp read err: %v: %v. Retry\n", attempt, fullURL, err)
-				reason = err.Error()
-				return
-			}
-		}()
-
-		if shouldRetry {
-			delay(r.RetryDelayMillis)
-			continue
+		a := &Attempt{
+			Num: attempt, Req: r, Request: r.reqRaw, RequestID: requestID,
+			Prev: prev, PrevErr: prevErr, authRetry: authRetry, ctx: ctx, span: span,
+		}
+		authRetry = false
+		logger.Log(ctx, LogDebug, "do request", logFields(val{"attempt", attempt}))
+		attemptStarted := time.Now()
+		var attemptResp *Resp
+		attemptResp, err = r.handler()(ctx, a)
+		hookFailed := false
+		if err == nil {
+			attemptResp, err = r.runResponseHooks(a, attemptResp)
+			hookFailed = err != nil
+		}
+		respRaw, content = nil, nil
+		if attemptResp != nil {
+			respRaw, content = attemptResp.RespRaw, attemptResp.Content
+		}
+		prev, prevErr = attemptResp, err
+		fullURL = a.Request.URL.String()
+
+		// short-circuited attempt has no timing
+		info := AttemptInfo{Num: attempt, Duration: time.Since(attemptStarted)}
+		if a.tt != nil {
+			info.Timing = a.tt.finish()
+			info.Duration = info.Timing.Total
+		}
+		if respRaw != nil {
+			info.Status = respRaw.StatusCode
 		}
 
-		if shouldRetryOnStatusCode(respRaw.StatusCode, r.RetryOnStatusCodes) {
-			golog.Warningf(
-				"att #%v: %v: got resp with repeatOnCodes. Code='%v', content='%s' . Retry\n",
-				attempt, fullURL, respRaw.StatusCode, content)
+		// reasons are redacted: they go to logs, history and errors
+		retryReason := ""
+		stop := false
+		var abort *abortError
+		var retry *retryError
+		switch {
+		case errors.As(err, &retry) && retry.auth:
+			retryReason = RetryReasonAuth
+			reason = rd.Text(err.Error(), fullURL)
+			authRetry = true
+			limit++
+		case errors.As(err, &retry):
+			retryReason = RetryReasonHook
+			reason = rd.Text(err.Error(), fullURL)
+		case hookFailed, errors.As(err, &abort):
+			stop = true
+			reason = rd.Text(err.Error(), fullURL)
+		case err != nil:
+			retryReason = RetryReasonError
+			reason = rd.Text(err.Error(), fullURL)
+		case shouldRetryOnStatusCode(respRaw.StatusCode, r.RetryOnStatusCodes):
+			retryReason = RetryReasonStatusCode
 			reason = fmt.Sprintf(
 				"finally got unwanted status code '%v' and content '%s'",
-				respRaw.StatusCode, content)
-			delay(r.RetryDelayMillis)
-			continue
-		}
-
-		if shouldRetryOnTextMarker(content, r.RetryOnTextMarkers) {
-			golog.Warningf(
-				"att #%v: %v: got resp with repeatOnTextMarkers. Code='%v', content='%s'. Retry\n",
-				attempt, fullURL, respRaw.StatusCode, content)
+				respRaw.StatusCode, rd.Body(respRaw.Header.Get("Content-Type"), content))
+		case shouldRetryOnTextMarker(content, r.RetryOnTextMarkers):
+			retryReason = RetryReasonTextMarker
 			reason = fmt.Sprintf(
 				"finally got unwanted text marker in resp with status code '%v' and content '%s'",
-				respRaw.StatusCode, content)
-			delay(r.RetryDelayMillis)
-			continue
+				respRaw.StatusCode, rd.Body(respRaw.Header.Get("Content-Type"), content))
+		default:
+			// no errors or retry cases
+			success = true
+		}
+
+		if !success {
+			info.Reason = reason
+		}
+		history = append(history, info)
+		if attempt == limit || stop {
+			retryReason = ""
+		}
+		if r.Metrics != nil {
+			r.Metrics.AttemptDone(labels, attempt, info.Status, info.Duration, retryReason)
+		}
+		if a.attemptSpan != nil {
+			a.attemptSpan.End(info, respRaw, err, retryReason)
+		}
+		if a.dbg != nil {
+			r.Debug.dump(rd, r.reqRaw, a.dbg, info, respRaw, content, retryReason != "")
+		}
+		if success {
+			break
+		}
+		logger.Log(ctx, LogWarn, "attempt failed", logFields(
+			val{"attempt", attempt}, val{"status", info.Status},
+			val{"duration", info.Duration}, val{"reason", reason},
+		))
+		if stop || ctx.Err() != nil {
+			// aborted, canceled or deadline exceeded: no more attempts
+			break
+		}
+		if attempt < limit && !authRetry {
+			delay(ctx, r.RetryDelayMillis)
+			if ctx.Err() != nil {
+				// canceled while waiting for the next attempt
+				reason += "; " + ctx.Err().Error()
+				break
+			}
 		}
-		// no errors or retry cases
-		success = true
-		break
 	}
 
-	myResp = Resp{Content: content, RespRaw: respRaw}
+	myResp := Resp{Content: content, RespRaw: respRaw, History: history, RequestID: requestID}
 
 	if !success {
 		// avoid duplicated url in the msg
+		redactedURL := rd.URL(fullURL)
 		msg := ""
-		if strings.Contains(reason, fullURL) {
+		if strings.Contains(reason, redactedURL) {
 			msg = reason
 		} else {
-			msg = fmt.Sprintf("%v %v: %v", r.Method, fullURL, reason)
+			msg = fmt.Sprintf("%v %v: %v", r.Method, redactedURL, reason)
+		}
+		if requestID != "" {
+			msg += fmt.Sprintf(" (request_id=%v)", requestID)
+		}
+		return finish(&myResp, errow.New("FAILED: ", msg))
+	}
+	logger.Log(ctx, LogDebug, "request succeeded", logFields(
+		val{"attempts", len(history)}, val{"status", respRaw.StatusCode},
+		val{"duration", time.Since(started)},
+	))
+	return finish(&myResp, nil)
+}
+
+// setProxyTransport replaces proxy transport of Req
+// closing idle connections of the previous one
+func (r *Req) setProxyTransport(t *http.Transport, proxyURL string) {
+	if r.proxyTransport != nil && r.proxyTransport != t {
+		r.proxyTransport.CloseIdleConnections()
+	}
+	r.proxyTransport, r.proxyTransportURL = t, proxyURL
+}
+
+// buildReqRaw builds reqRaw with ctx from Req fields and returns full URL
+func (r *Req) buildReqRaw(ctx context.Context) (string, error) {
+	if r.ProxyURL == "" {
+		r.setProxyTransport(nil, "")
+	} else if r.proxyTransport == nil || r.proxyTransportURL != r.ProxyURL {
+		proxyURL, err := url.Parse(r.ProxyURL)
+		if err != nil {
+			// the error contains the url with password
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			return "", errow.New("bad proxy url: ", r.redactor().Text(err.Error()))
+		}
+		// Client (and its Transport) may be shared, so the proxy
+		// is set on a copy of the Transport
+		var t *http.Transport
+		switch ct := r.Client.Transport.(type) {
+		case nil:
+			t = http.DefaultTransport.(*http.Transport).Clone()
+		case *http.Transport:
+			t = ct.Clone()
+		default:
+			return "", errow.Newf("can't set proxy for Client.Transport of type %T", ct)
+		}
+		t.Proxy = http.ProxyURL(proxyURL)
+		r.setProxyTransport(t, r.ProxyURL)
+	}
+
+	path, err := expandPath(r.Path, r.PathParams)
+	if err != nil {
+		return "", errow.Wrap(err, "bad path")
+	}
+
+	fullURL, err := buildFullURL(r.URL, path, r.Params, r.ParamsStyle)
+	if err != nil {
+		return "", errow.Wrap(err, "bad full url")
+	}
+
+	reqBody, err := r.bodyReader()
+	if err != nil {
+		return "", errow.Wrap(err, "bad body")
+	}
+	compressed := false
+	if r.Compress != "" {
+		reqBody, compressed, err = compressBody(r.Compress, r.CompressMinBytes, reqBody)
+		if err != nil {
+			return "", errow.Wrap(err, "can't compress body")
 		}
-		return &myResp, errow.New("FAILED: ", msg)
 	}
-	golog.Traceln("SUCCESS:", r.Method, fullURL)
-	return &myResp, nil
+
+	r.reqRaw, err = http.NewRequestWithContext(ctx, r.Method, fullURL, reqBody)
+	if err != nil {
+		return "", errow.Wrap(err, "bad req raw")
+	}
+	setCookies(r.reqRaw, r.Cookies)
+	setHeaders(r.reqRaw, r.Headers, r.HeadersAdd)
+	if r.Form != nil && r.BodyStream == nil && r.reqRaw.Header.Get("Content-Type") == "" {
+		r.reqRaw.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if compressed {
+		r.reqRaw.Header.Set("Content-Encoding", r.Compress)
+	}
+	return fullURL, nil
+}
+
+// prepareReqRaw builds reqRaw with request ID headers and auth
+// for the attempt and returns full URL
+func (r *Req) prepareReqRaw(ctx context.Context, requestID string, attempt int) (string, error) {
+	fullURL, err := r.buildReqRaw(ctx)
+	if err != nil {
+		return "", err
+	}
+	r.setRequestIDHeaders(requestID, attempt)
+	if err = r.authenticate(); err != nil {
+		return "", err
+	}
+	return fullURL, nil
+}
+
+// do sends reqRaw and reads the response content.
+// Response body is closed even if reading failed
+func (r *Req) do(tt *timingTrace) (*http.Response, []byte, error) {
+	respRaw, err := r.client().Do(r.reqRaw)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer respRaw.Body.Close()
+	readStarted := time.Now()
+	content, err := io.ReadAll(respRaw.Body)
+	tt.setBodyRead(time.Since(readStarted))
+	if err != nil {
+		return respRaw, content, err
+	}
+	return respRaw, content, nil
 }
 
 // Get is shortcut to send GET method
@@ -345,36 +635,87 @@ func (r *Req) WithBody(body string) *Req {
 	return r
 }
 
-// WithPath is a build func for Path field from any parts (uses fmt.Sprint)
+// WithBodyStream is a build func for BodyStream field
+func (r *Req) WithBodyStream(stream func() (io.Reader, error)) *Req {
+	r.BodyStream = stream
+	return r
+}
+
+// WithCompress is a build func for Compress and CompressMinBytes fields
+func (r *Req) WithCompress(encoding string, minBytes int) *Req {
+	r.Compress = encoding
+	r.CompressMinBytes = minBytes
+	return r
+}
+
+// WithPath is a build func for Path field from any parts (uses fmt.Sprint).
+// Note, that parts are not escaped, use WithPathParams for user input
 func (r *Req) WithPath(parts ...any) *Req {
 	r.Path = fmt.Sprint(parts...)
 	return r
 }
 
+// WithPathParams is a build func for Path template and PathParams fields
+func (r *Req) WithPathParams(template string, params Vals) *Req {
+	r.Path = template
+	r.PathParams = params
+	return r
+}
+
 // WithParams is a build func for Params field
 func (r *Req) WithParams(params Vals) *Req {
 	r.Params = params
 	return r
 }
 
+// WithParamsStyle is a build func for ParamsStyle field
+func (r *Req) WithParamsStyle(style EncodeStyle) *Req {
+	r.ParamsStyle = style
+	return r
+}
+
+// WithFormStyle is a build func for FormStyle field
+func (r *Req) WithFormStyle(style EncodeStyle) *Req {
+	r.FormStyle = style
+	return r
+}
+
 // WithHeaders is a build func for Headers field
 func (r *Req) WithHeaders(headers Vals) *Req {
 	r.Headers = headers
 	return r
 }
 
+// WithHeadersAdd is a build func for HeadersAdd field
+func (r *Req) WithHeadersAdd(add bool) *Req {
+	r.HeadersAdd = add
+	return r
+}
+
 // WithProxyURL is a build func for ProxyURL field
 func (r *Req) WithProxyURL(proxyURL string) *Req {
 	r.ProxyURL = proxyURL
 	return r
 }
 
-// WithRetryOnTextMarkers is a build func for RetryOnTextMarkers field
+// WithMiddleware is a build func for Middleware field
 func (r *Req) WithMiddleware(funcs []func()) *Req {
 	r.Middleware = funcs
 	return r
 }
 
+// WithInterceptors is a build func for Interceptors field
+func (r *Req) WithInterceptors(interceptors ...Interceptor) *Req {
+	r.Interceptors = interceptors
+	return r
+}
+
+// WithResponseHooks is a build func for ResponseHooks field
+func (r *Req) WithResponseHooks(hooks ...ResponseHook) *Req {
+	r.ResponseHooks = hooks
+	return r
+}
+
 // WithRetryOnTextMarkers is a build func for RetryOnTextMarkers field
 func (r *Req) WithRetryOnTextMarkers(markers []string) *Req {
 	r.RetryOnTextMarkers = markers
@@ -411,6 +752,60 @@ func (r *Req) WithCookies(cookies []*http.Cookie) *Req {
 	return r
 }
 
+// WithLogger is a build func for Logger field
+func (r *Req) WithLogger(logger Logger) *Req {
+	r.Logger = logger
+	return r
+}
+
+// WithRedactor is a build func for Redactor field
+func (r *Req) WithRedactor(redactor *Redactor) *Req {
+	r.Redactor = redactor
+	return r
+}
+
+// WithDebug is a build func for Debug field
+func (r *Req) WithDebug(debug *Debug) *Req {
+	r.Debug = debug
+	return r
+}
+
+// WithMetrics is a build func for Metrics field
+func (r *Req) WithMetrics(metrics Metrics) *Req {
+	r.Metrics = metrics
+	return r
+}
+
+// WithRequestID is a build func for RequestIDFunc field
+func (r *Req) WithRequestID(f RequestIDFunc) *Req {
+	r.RequestIDFunc = f
+	return r
+}
+
+// WithAttemptHeader is a build func for AttemptHeader field
+func (r *Req) WithAttemptHeader(header string) *Req {
+	r.AttemptHeader = header
+	return r
+}
+
+// WithTracer is a build func for Tracer field
+func (r *Req) WithTracer(tracer Tracer) *Req {
+	r.Tracer = tracer
+	return r
+}
+
+// WithContext is a build func for Context field
+func (r *Req) WithContext(ctx context.Context) *Req {
+	r.Context = ctx
+	return r
+}
+
+// WithAuth is a build func for Auth field
+func (r *Req) WithAuth(auth Auth) *Req {
+	r.Auth = auth
+	return r
+}
+
 // WithClient is a build func for Client field
 func (r *Req) WithClient(client *http.Client) *Req {
 	r.Client = client
@@ -1,7 +1,10 @@
 package req
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
@@ -284,3 +287,111 @@ func TestWithPath(t *testing.T) {
 		t.Error("bad path", r.Path)
 	}
 }
+
+func TestReqGet_HeadersAdd(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Host, " ", strings.Join(r.Header.Values("Accept"), ","))
+	}))
+	defer srv.Close()
+
+	r := New(srv.URL).WithHeadersAdd(true).WithHeaders(Vals{
+		{"Host", "example.com"},
+		{"Accept", "text/plain"},
+		{"Accept", "application/json"},
+	})
+	resp, err := r.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "example.com text/plain,application/json" {
+		t.Fatal("Unexpected response:", resp.Text())
+	}
+}
+
+func TestReqPost_FormContentType(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Join(r.Header.Values("Content-Type"), ","))
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		r    *Req
+		want string
+	}{
+		{New(srv.URL), "application/x-www-form-urlencoded"},
+		{New(srv.URL).WithHeaders(Vals{{"Content-Type", "text/plain"}}), "text/plain"},
+		{New(srv.URL).WithHeadersAdd(true).WithHeaders(Vals{{"Content-Type", "text/plain"}}), "text/plain"},
+	}
+	for _, c := range cases {
+		resp, err := c.r.WithForm(Vals{{"a", 1}}).Post()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text() != c.want {
+			t.Fatal("Unexpected Content-Type:", resp.Text())
+		}
+	}
+}
+
+func TestReqGet_PathParams(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.URL.EscapedPath())
+	}))
+	defer srv.Close()
+
+	r := New(srv.URL).WithPathParams("/users/{id}/orders/{orderID}", Vals{
+		{"id", "../admin"},
+		{"orderID", 10},
+	})
+	resp, err := r.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "/users/..%2Fadmin/orders/10" {
+		t.Fatal("Unexpected response:", resp.Text())
+	}
+	if r.Path != "/users/{id}/orders/{orderID}" {
+		t.Fatal("Unexpected path template:", r.Path)
+	}
+
+	r.PathParams = Vals{{"id", 1}}
+	if _, err = r.Get(); err == nil {
+		t.Fatal("Expected err, but got nil")
+	}
+}
+
+func TestReqSend_ContextCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	resp, err := New(srv.URL).WithContext(ctx).WithAttempts(3).WithLogger(NopLogger).Get()
+	if err == nil {
+		t.Fatal("Expected err, but got nil")
+	}
+	if len(resp.History) != 1 || !strings.Contains(err.Error(), "context canceled") {
+		t.Fatal("Unexpected result:", resp.History, err)
+	}
+}
+
+func TestReqSend_ContextCanceledDelay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	resp, err := New(srv.URL).WithContext(ctx).WithAttempts(3).WithRetryDelayMillis(10000).
+		WithLogger(NopLogger).Get()
+	if err == nil {
+		t.Fatal("Expected err, but got nil")
+	}
+	// the delay is interrupted
+	if time.Since(started) > 5*time.Second || len(resp.History) != 1 ||
+		!strings.Contains(err.Error(), "context deadline exceeded") {
+		t.Fatal("Unexpected result:", time.Since(started), resp.History, err)
+	}
+}
@@ -0,0 +1,24 @@
+module github.com/nordborn/go-req/reqotel
+
+go 1.21
+
+require (
+	github.com/nordborn/go-req v0.0.0
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/sdk v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/klauspost/compress v1.17.11 // indirect
+	github.com/nordborn/go-errow v1.0.1 // indirect
+	github.com/nordborn/golog v0.0.0-20190110093311-983a5529802d // indirect
+	github.com/pkg/errors v0.8.0 // indirect
+	go.opentelemetry.io/otel/metric v1.28.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+)
+
+replace github.com/nordborn/go-req => ../
@@ -0,0 +1,195 @@
+// Package reqotel provides OpenTelemetry adapter for req.Tracer.
+//
+// Example:
+//
+//	s := req.NewSession()
+//	s.Tracer = reqotel.New(nil, nil) // global TracerProvider, W3C propagation
+//	resp, err := s.New("http://httpbin.org").WithContext(ctx).WithPath("get").Get()
+//
+// Each Send gets an internal span named "METHOD path-template"
+// with client spans of its attempts as children.
+// Attempt spans have HTTP semantic conventions attributes,
+// retries are recorded as "retry" events of the request span,
+// trace context (traceparent) and baggage are injected
+// into request headers of each attempt
+package reqotel
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/nordborn/go-req"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope name of the tracer
+const ScopeName = "github.com/nordborn/go-req/reqotel"
+
+// Tracer implements req.Tracer with OpenTelemetry
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+var _ req.Tracer = (*Tracer)(nil)
+
+// New creates Tracer using tp (otel.GetTracerProvider() if nil)
+// and propagator (W3C trace context and baggage if nil)
+func New(tp trace.TracerProvider, propagator propagation.TextMapPropagator) *Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	if propagator == nil {
+		propagator = propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{}, propagation.Baggage{})
+	}
+	return &Tracer{
+		tracer:     tp.Tracer(ScopeName),
+		propagator: propagator,
+	}
+}
+
+// StartRequest implements req.Tracer
+func (t *Tracer) StartRequest(ctx context.Context, r *req.Req) (context.Context, req.RequestSpan) {
+	name := spanName(r)
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(r.Method),
+		semconv.URLTemplate(r.Path),
+	}
+	if id := req.RequestIDFromContext(ctx); id != "" {
+		attrs = append(attrs, attribute.String("req.request_id", id))
+	}
+	ctx, span := t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attrs...))
+	return ctx, &requestSpan{t: t, r: r, name: name, span: span}
+}
+
+// spanName returns "METHOD path-template" or "METHOD" without Path
+func spanName(r *req.Req) string {
+	if r.Path == "" {
+		return r.Method
+	}
+	return r.Method + " " + r.Path
+}
+
+type requestSpan struct {
+	t    *Tracer
+	r    *req.Req
+	name string
+	span trace.Span
+}
+
+// StartAttempt implements req.RequestSpan
+func (s *requestSpan) StartAttempt(ctx context.Context, attempt int, request *http.Request) (*http.Request, req.AttemptSpan) {
+	rd := s.r.Redactor
+	if rd == nil {
+		rd = req.DefaultRedactor
+	}
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(request.Method),
+		semconv.URLFull(rd.URL(request.URL.String())),
+		semconv.ServerAddress(request.URL.Hostname()),
+	}
+	if port := serverPort(request.URL.Scheme, request.URL.Port()); port > 0 {
+		attrs = append(attrs, semconv.ServerPort(port))
+	}
+	if s.r.Path != "" {
+		attrs = append(attrs, semconv.URLTemplate(s.r.Path))
+	}
+	if attempt > 1 {
+		attrs = append(attrs, semconv.HTTPRequestResendCount(attempt-1))
+	}
+
+	ctx, span := s.t.tracer.Start(ctx, s.name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+	request = request.WithContext(ctx)
+	s.t.propagator.Inject(ctx, propagation.HeaderCarrier(request.Header))
+	return request, &attemptSpan{parent: s, span: span}
+}
+
+// End implements req.RequestSpan
+func (s *requestSpan) End(resp *req.Resp, err error) {
+	if resp != nil && resp.RespRaw != nil {
+		s.span.SetAttributes(semconv.HTTPResponseStatusCode(resp.RespRaw.StatusCode))
+	}
+	if resp != nil {
+		s.span.SetAttributes(attribute.Int("req.attempts", len(resp.History)))
+	}
+	if err != nil {
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+type attemptSpan struct {
+	parent *requestSpan
+	span   trace.Span
+}
+
+// End implements req.AttemptSpan
+func (s *attemptSpan) End(info req.AttemptInfo, respRaw *http.Response, err error, retry string) {
+	switch {
+	case err != nil:
+		s.span.SetAttributes(semconv.ErrorTypeKey.String(errorType(err)))
+		s.span.SetStatus(codes.Error, info.Reason)
+	case respRaw != nil:
+		s.span.SetAttributes(semconv.HTTPResponseStatusCode(respRaw.StatusCode))
+		if respRaw.StatusCode >= 400 {
+			s.span.SetAttributes(semconv.ErrorTypeKey.String(strconv.Itoa(respRaw.StatusCode)))
+			s.span.SetStatus(codes.Error, "")
+		} else if info.Reason != "" {
+			s.span.SetStatus(codes.Error, info.Reason)
+		}
+	}
+	if info.Timing.RemoteAddr != "" {
+		if host, port, err := net.SplitHostPort(info.Timing.RemoteAddr); err == nil {
+			s.span.SetAttributes(semconv.NetworkPeerAddress(host))
+			if p, err := strconv.Atoi(port); err == nil {
+				s.span.SetAttributes(semconv.NetworkPeerPort(p))
+			}
+		}
+	}
+	s.span.End()
+
+	if retry != "" {
+		s.parent.span.AddEvent("retry", trace.WithAttributes(
+			attribute.Int("req.attempt", info.Num),
+			attribute.String("req.retry.reason", retry),
+			attribute.String("req.retry.details", info.Reason),
+		))
+	}
+}
+
+// serverPort returns explicit port or default port of the scheme
+func serverPort(scheme, port string) int {
+	if port != "" {
+		p, _ := strconv.Atoi(port)
+		return p
+	}
+	switch scheme {
+	case "http":
+		return 80
+	case "https":
+		return 443
+	}
+	return 0
+}
+
+// errorType returns low-cardinality type of transport error
+func errorType(err error) string {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+	return semconv.ErrorTypeOther.Value.AsString()
+}
@@ -0,0 +1,114 @@
+package reqotel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nordborn/go-req"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func attr(attrs []attribute.KeyValue, key string) attribute.Value {
+	for _, a := range attrs {
+		if string(a.Key) == key {
+			return a.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracer(t *testing.T) {
+	var traceparents, baggages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get("Traceparent"))
+		baggages = append(baggages, r.Header.Get("Baggage"))
+		if len(traceparents) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer tp.Shutdown(context.Background())
+
+	member, _ := baggage.NewMember("tenant", "t1")
+	bag, _ := baggage.New(member)
+	ctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+	var pathParams req.Vals
+	pathParams.Add("id", 1)
+	r := req.New(srv.URL).WithTracer(New(tp, nil)).WithContext(ctx).WithAttempts(2).
+		WithLogger(req.NopLogger).WithPathParams("/users/{id}", pathParams)
+	if _, err := r.Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatal("Unexpected spans:", len(spans))
+	}
+	att1, att2, request := spans[0], spans[1], spans[2]
+	if request.Name != "GET /users/{id}" || request.SpanKind != trace.SpanKindInternal {
+		t.Fatal("Unexpected request span:", request.Name, request.SpanKind)
+	}
+	for i, att := range []tracetest.SpanStub{att1, att2} {
+		if att.Parent.SpanID() != request.SpanContext.SpanID() || att.SpanKind != trace.SpanKindClient {
+			t.Fatal("Unexpected attempt span parent or kind:", att.Name)
+		}
+		if traceparents[i] != "00-"+att.SpanContext.TraceID().String()+"-"+att.SpanContext.SpanID().String()+"-01" {
+			t.Fatal("Unexpected traceparent:", traceparents[i])
+		}
+		if baggages[i] != "tenant=t1" {
+			t.Fatal("Unexpected baggage:", baggages[i])
+		}
+	}
+
+	if attr(att1.Attributes, "http.response.status_code").AsInt64() != 502 ||
+		attr(att1.Attributes, "error.type").AsString() != "502" ||
+		att1.Status.Code != codes.Error {
+		t.Fatal("Unexpected first attempt:", att1.Attributes, att1.Status)
+	}
+	if attr(att2.Attributes, "http.request.resend_count").AsInt64() != 1 ||
+		attr(att2.Attributes, "url.full").AsString() != srv.URL+"/users/1" ||
+		attr(att2.Attributes, "url.template").AsString() != "/users/{id}" ||
+		att2.Status.Code != codes.Unset {
+		t.Fatal("Unexpected second attempt:", att2.Attributes, att2.Status)
+	}
+	if len(request.Events) != 1 || request.Events[0].Name != "retry" ||
+		attr(request.Events[0].Attributes, "req.retry.reason").AsString() != req.RetryReasonStatusCode {
+		t.Fatal("Unexpected events:", request.Events)
+	}
+}
+
+func TestTracer_Failed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer tp.Shutdown(context.Background())
+
+	r := req.New(srv.URL).WithTracer(New(tp, nil)).WithLogger(req.NopLogger).
+		WithParams(nil)
+	r.Params.Add("token", "secret")
+	if _, err := r.Get(); err == nil {
+		t.Fatal("Expected err, but got nil")
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 2 || spans[1].Status.Code != codes.Error || len(spans[1].Events) != 0 {
+		t.Fatal("Unexpected spans:", spans)
+	}
+	if u := attr(spans[0].Attributes, "url.full").AsString(); u != srv.URL+"?token=[REDACTED]" {
+		t.Fatal("Unexpected url:", u)
+	}
+}
@@ -0,0 +1,126 @@
+// Package reqprom provides Prometheus adapter for req.Metrics.
+//
+// Example:
+//
+//	metrics, err := reqprom.New(prometheus.DefaultRegisterer, "myapp")
+//	...
+//	s := req.NewSession()
+//	s.Metrics = metrics
+//	resp, err := s.New("http://httpbin.org").WithPath("get").Get()
+package reqprom
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/nordborn/go-errow"
+	"github.com/nordborn/go-req"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics implements req.Metrics with Prometheus collectors
+// labeled by method, host and path template:
+//
+//	<namespace>_http_client_requests_total{method,host,path,code,result}
+//	<namespace>_http_client_attempts_total{method,host,path,code}
+//	<namespace>_http_client_retries_total{method,host,path,reason}
+//	<namespace>_http_client_requests_in_flight{method,host,path}
+//	<namespace>_http_client_request_duration_seconds{method,host,path}
+//	<namespace>_http_client_attempt_duration_seconds{method,host,path}
+//
+// code is the status code ("0" if there is no response),
+// result is "success" or "failure"
+type Metrics struct {
+	requests        *prometheus.CounterVec
+	attempts        *prometheus.CounterVec
+	retries         *prometheus.CounterVec
+	inFlight        *prometheus.GaugeVec
+	requestDuration *prometheus.HistogramVec
+	attemptDuration *prometheus.HistogramVec
+}
+
+var _ req.Metrics = (*Metrics)(nil)
+
+// New creates Metrics and registers its collectors in reg
+// (prometheus.DefaultRegisterer if reg is nil).
+// Histograms use prometheus.DefBuckets
+func New(reg prometheus.Registerer, namespace string) (*Metrics, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	labels := []string{"method", "host", "path"}
+	m := &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "requests_total",
+			Help:      "Number of requests (each with one or more attempts).",
+		}, append(labels, "code", "result")),
+		attempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "attempts_total",
+			Help:      "Number of request attempts.",
+		}, append(labels, "code")),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "retries_total",
+			Help:      "Number of retries by reason.",
+		}, append(labels, "reason")),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "requests_in_flight",
+			Help:      "Number of requests in progress.",
+		}, labels),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of requests including all attempts and retry delays.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+		attemptDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "attempt_duration_seconds",
+			Help:      "Duration of request attempts.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+	}
+
+	for _, c := range []prometheus.Collector{
+		m.requests, m.attempts, m.retries, m.inFlight, m.requestDuration, m.attemptDuration,
+	} {
+		if err := reg.Register(c); err != nil {
+			return nil, errow.Wrap(err, "can't register collector")
+		}
+	}
+	return m, nil
+}
+
+// RequestStarted implements req.Metrics
+func (m *Metrics) RequestStarted(l req.MetricLabels) {
+	m.inFlight.WithLabelValues(l.Method, l.Host, l.Path).Inc()
+}
+
+// AttemptDone implements req.Metrics
+func (m *Metrics) AttemptDone(l req.MetricLabels, _, status int, duration time.Duration, retry string) {
+	m.attempts.WithLabelValues(l.Method, l.Host, l.Path, strconv.Itoa(status)).Inc()
+	m.attemptDuration.WithLabelValues(l.Method, l.Host, l.Path).Observe(duration.Seconds())
+	if retry != "" {
+		m.retries.WithLabelValues(l.Method, l.Host, l.Path, retry).Inc()
+	}
+}
+
+// RequestDone implements req.Metrics
+func (m *Metrics) RequestDone(l req.MetricLabels, status, _ int, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	m.inFlight.WithLabelValues(l.Method, l.Host, l.Path).Dec()
+	m.requests.WithLabelValues(l.Method, l.Host, l.Path, strconv.Itoa(status), result).Inc()
+	m.requestDuration.WithLabelValues(l.Method, l.Host, l.Path).Observe(duration.Seconds())
+}
@@ -0,0 +1,61 @@
+package reqprom
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nordborn/go-req"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	m, err := New(reg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pathParams req.Vals
+	pathParams.Add("id", 1)
+	r := req.New(srv.URL).WithMetrics(m).WithAttempts(2).WithLogger(req.NopLogger).
+		WithPathParams("/users/{id}", pathParams)
+	if _, err = r.Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	labels := []string{"GET", srv.Listener.Addr().String(), "/users/{id}"}
+	assertions := []struct {
+		name     string
+		c        prometheus.Collector
+		expected float64
+	}{
+		{"requests", m.requests.WithLabelValues(append(labels, "200", "success")...), 1},
+		{"attempts 503", m.attempts.WithLabelValues(append(labels, "503")...), 1},
+		{"attempts 200", m.attempts.WithLabelValues(append(labels, "200")...), 1},
+		{"retries", m.retries.WithLabelValues(append(labels, req.RetryReasonStatusCode)...), 1},
+		{"in flight", m.inFlight.WithLabelValues(labels...), 0},
+	}
+	for _, a := range assertions {
+		if v := testutil.ToFloat64(a.c); v != a.expected {
+			t.Errorf("%v: %v != %v\n", a.name, v, a.expected)
+		}
+	}
+	if n := testutil.CollectAndCount(m.requestDuration); n != 1 {
+		t.Error("Unexpected request duration series:", n)
+	}
+
+	if _, err = New(reg, "test"); err == nil {
+		t.Fatal("Expected duplicated registration err, but got nil")
+	}
+}
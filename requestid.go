@@ -0,0 +1,115 @@
+package req
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRequestIDHeader is used if Req.RequestIDHeader is empty
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// RequestIDFunc generates request (correlation) IDs, see UUIDv4 and ULID
+type RequestIDFunc func() string
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns ctx carrying the request ID.
+// Send uses an ID from Req.Context instead of generating a new one,
+// so an incoming request ID can be propagated to outbound calls
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx or ""
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// UUIDv4 generates random UUID (RFC 9562 version 4)
+func UUIDv4() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // variant 10
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], b[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], b[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], b[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], b[10:])
+	return string(s[:])
+}
+
+// crockford is Crockford's base32 alphabet used by ULID
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID generates lexicographically sortable ID: 48 bits of Unix time
+// in milliseconds and 80 random bits as 26 chars of Crockford's base32
+func ULID() string {
+	var b [16]byte
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(time.Now().UnixMilli()))
+	copy(b[:6], ts[2:])
+	rand.Read(b[6:])
+
+	// 128 bits are encoded as 130 bits with 2 leading zero bits
+	var s [26]byte
+	for i := range s {
+		var v byte
+		for bit := i*5 - 2; bit < i*5+3; bit++ {
+			v <<= 1
+			if bit >= 0 && b[bit/8]&(0x80>>(bit%8)) != 0 {
+				v |= 1
+			}
+		}
+		s[i] = crockford[v]
+	}
+	return string(s[:])
+}
+
+// requestIDHeader returns Req.RequestIDHeader or DefaultRequestIDHeader
+func (r *Req) requestIDHeader() string {
+	if r.RequestIDHeader != "" {
+		return r.RequestIDHeader
+	}
+	return DefaultRequestIDHeader
+}
+
+// requestID returns ID from Headers, from ctx or a new one from RequestIDFunc
+// ("" if there is no ID and no RequestIDFunc)
+func (r *Req) requestID(ctx context.Context) string {
+	header := r.requestIDHeader()
+	for _, v := range r.Headers {
+		if strings.EqualFold(v.K, header) {
+			return fmt.Sprint(v.V)
+		}
+	}
+	if id := RequestIDFromContext(ctx); id != "" {
+		return id
+	}
+	if r.RequestIDFunc != nil {
+		return r.RequestIDFunc()
+	}
+	return ""
+}
+
+// setRequestIDHeaders sets request ID and attempt headers of reqRaw
+func (r *Req) setRequestIDHeaders(id string, attempt int) {
+	if id != "" {
+		r.reqRaw.Header.Set(r.requestIDHeader(), id)
+	}
+	if r.AttemptHeader != "" {
+		r.reqRaw.Header.Set(r.AttemptHeader, strconv.Itoa(attempt))
+	}
+}
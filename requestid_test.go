@@ -0,0 +1,89 @@
+package req
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestUUIDv4(t *testing.T) {
+	re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	id1, id2 := UUIDv4(), UUIDv4()
+	if !re.MatchString(id1) || id1 == id2 {
+		t.Fatal("Unexpected UUIDs:", id1, id2)
+	}
+}
+
+func TestULID(t *testing.T) {
+	re := regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+	id1, id2 := ULID(), ULID()
+	if !re.MatchString(id1) || id1 == id2 {
+		t.Fatal("Unexpected ULIDs:", id1, id2)
+	}
+	// time prefix is sortable
+	if id1[:8] > id2[:8] {
+		t.Fatal("Unexpected order:", id1, id2)
+	}
+}
+
+func TestReqSend_RequestID(t *testing.T) {
+	var ids, attempts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.Header.Get("X-Request-ID"))
+		attempts = append(attempts, r.Header.Get("X-Attempt"))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	logger := &testLogger{}
+	r := New(srv.URL).WithRequestID(func() string { return "gen-id" }).
+		WithAttemptHeader("X-Attempt").WithAttempts(2).WithLogger(logger)
+	resp, err := r.Get()
+	if err == nil {
+		t.Fatal("Expected err, but got nil")
+	}
+	if strings.Join(ids, ",") != "gen-id,gen-id" || strings.Join(attempts, ",") != "1,2" {
+		t.Fatal("Unexpected headers:", ids, attempts)
+	}
+	if resp.RequestID != "gen-id" || !strings.Contains(err.Error(), "request_id=gen-id") {
+		t.Fatal("Unexpected request id:", resp.RequestID, err)
+	}
+	for _, rec := range logger.records {
+		if rec.fields.Get("request_id") != "gen-id" {
+			t.Fatal("Unexpected log record:", rec)
+		}
+	}
+
+	// incoming ID from context
+	ids = nil
+	ctx := ContextWithRequestID(context.Background(), "incoming-id")
+	resp, _ = r.WithContext(ctx).WithAttempts(1).Get()
+	if resp.RequestID != "incoming-id" || ids[0] != "incoming-id" {
+		t.Fatal("Unexpected request id:", resp.RequestID, ids)
+	}
+
+	// explicit header
+	ids = nil
+	resp, _ = r.WithHeaders(Vals{{"x-request-id", "header-id"}}).Get()
+	if resp.RequestID != "header-id" || ids[0] != "header-id" {
+		t.Fatal("Unexpected request id:", resp.RequestID, ids)
+	}
+}
+
+func TestReqSend_NoRequestID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get(DefaultRequestIDHeader)))
+	}))
+	defer srv.Close()
+
+	resp, err := New(srv.URL).WithLogger(NopLogger).Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.RequestID != "" || resp.Text() != "" {
+		t.Fatal("Unexpected request id:", resp.RequestID, resp.Text())
+	}
+}
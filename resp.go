@@ -2,18 +2,38 @@ package req
 
 import (
 	"encoding/json"
-	"github.com/nordborn/go-errow"
 	"net/http"
+	"time"
+
+	"github.com/nordborn/go-errow"
 )
 
 // Resp represents the HTTP response
 // Resp public fields:
 // Content - response body as slice of bytes
 // RespRaw - underlying *http.Response, it's public to provide ability for low-level access
+// History - info about each attempt of the request (including the last one)
+// RequestID - request (correlation) ID sent with the request (see Req.RequestIDFunc)
 type Resp struct {
-	Content []byte
-	RespRaw *http.Response
-	text    string
+	Content   []byte
+	RespRaw   *http.Response
+	History   []AttemptInfo
+	RequestID string
+	text      string
+}
+
+// AttemptInfo describes single attempt of Send
+type AttemptInfo struct {
+	// Num is the number of the attempt starting from 1
+	Num int
+	// Status is the response status code (0 if there is no response)
+	Status int
+	// Duration of the attempt (request and response reading)
+	Duration time.Duration
+	// Reason why the attempt failed ("" for successful attempt)
+	Reason string
+	// Timing is a breakdown of the attempt duration
+	Timing Timing
 }
 
 // Text returns string of Content of the resp.
@@ -25,6 +45,14 @@ func (resp *Resp) Text() string {
 	return resp.text
 }
 
+// Timing returns timing breakdown of the last attempt
+func (resp *Resp) Timing() Timing {
+	if len(resp.History) == 0 {
+		return Timing{}
+	}
+	return resp.History[len(resp.History)-1].Timing
+}
+
 // Cookies returns cookies of underlying response
 // as default []*http.Cookie
 func (resp *Resp) Cookies() []*http.Cookie {
@@ -46,3 +74,14 @@ func (resp *Resp) JSON(unmarshalToPtr interface{}) error {
 	}
 	return nil
 }
+
+// Vals decodes response content (JSON object) to ordered Vals.
+// Nested objects become Vals, arrays become []any
+// (see Vals.UnmarshalJSON)
+func (resp *Resp) Vals() (Vals, error) {
+	var vals Vals
+	if err := vals.UnmarshalJSON(resp.Content); err != nil {
+		return nil, err
+	}
+	return vals, nil
+}
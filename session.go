@@ -0,0 +1,78 @@
+package req
+
+import "net/http"
+
+// Session keeps settings shared by requests created with Session.New.
+// The settings are copied to each new Req, so they can be
+// overridden per Req, and changes of the Session don't affect
+// already created Reqs
+type Session struct {
+	// Client is used by all requests of the session.
+	// Default is http.DefaultClient
+	Client *http.Client
+
+	// Logger receives structured log records of the session requests.
+	// Default is nil (DefaultLogger is used)
+	Logger Logger
+
+	// Redactor hides secrets of the session requests.
+	// Default is nil (DefaultRedactor is used)
+	Redactor *Redactor
+
+	// Debug dumps requests and responses of the session requests.
+	// Default is nil (no dumps)
+	Debug *Debug
+
+	// Metrics receives measurements of the session requests.
+	// Default is nil (no metrics)
+	Metrics Metrics
+
+	// Tracer instruments the session requests with spans.
+	// Default is nil (no tracing)
+	Tracer Tracer
+
+	// RequestIDFunc, RequestIDHeader and AttemptHeader
+	// are request ID settings of the session requests (see Req)
+	RequestIDFunc   RequestIDFunc
+	RequestIDHeader string
+	AttemptHeader   string
+
+	// Interceptors wrap each attempt of the session requests
+	// (see Req.Interceptors)
+	Interceptors []Interceptor
+
+	// ResponseHooks are called after each attempt response
+	// of the session requests (see Req.ResponseHooks)
+	ResponseHooks []ResponseHook
+
+	// Auth authenticates the session requests (see Req.Auth)
+	Auth Auth
+}
+
+// NewSession generates Session with default arguments
+func NewSession() *Session {
+	return &Session{
+		Client: http.DefaultClient,
+	}
+}
+
+// New generates Req with default arguments (see req.New)
+// and settings of the session
+func (s *Session) New(url string) *Req {
+	r := New(url)
+	if s.Client != nil {
+		r.Client = s.Client
+	}
+	r.Logger = s.Logger
+	r.Redactor = s.Redactor
+	r.Debug = s.Debug
+	r.Metrics = s.Metrics
+	r.Tracer = s.Tracer
+	r.RequestIDFunc = s.RequestIDFunc
+	r.RequestIDHeader = s.RequestIDHeader
+	r.AttemptHeader = s.AttemptHeader
+	r.Interceptors = append([]Interceptor(nil), s.Interceptors...)
+	r.ResponseHooks = append([]ResponseHook(nil), s.ResponseHooks...)
+	r.Auth = s.Auth
+	return r
+}
@@ -0,0 +1,57 @@
+package req
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSession_New(t *testing.T) {
+	client := &http.Client{}
+	logger := &testLogger{}
+	s := NewSession()
+	s.Client = client
+	s.Logger = logger
+
+	r := s.New("http://httpbin.org")
+	if r.Client != client || r.Logger != logger || r.Attempts != 1 {
+		t.Fatal("Unexpected req:", r)
+	}
+}
+
+func TestSession_Concurrent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http.Transport{}}
+	s := NewSession()
+	s.Client = client
+	s.Logger = NopLogger
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r := s.New(srv.URL).WithTimeout(time.Duration(i+1) * time.Second)
+			if i%2 == 0 {
+				// proxy is the server itself
+				r.WithProxyURL(srv.URL)
+			}
+			_, err := r.Get()
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if client.Timeout != 0 || client.Transport.(*http.Transport).Proxy != nil {
+		t.Fatal("Unexpected client changes:", client)
+	}
+}
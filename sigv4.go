@@ -0,0 +1,245 @@
+package req
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nordborn/go-errow"
+)
+
+// AWS SigV4 constants
+const (
+	awsAlgorithm       = "AWS4-HMAC-SHA256"
+	awsTimeFormat      = "20060102T150405Z"
+	awsUnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// awsSkipHeaders are not signed: they can be changed by proxies and transport
+var awsSkipHeaders = map[string]bool{
+	"authorization":     true,
+	"user-agent":        true,
+	"x-amzn-trace-id":   true,
+	"expect":            true,
+	"connection":        true,
+	"transfer-encoding": true,
+}
+
+// AWSSigner is Auth which signs each attempt with AWS Signature Version 4
+// (S3, MinIO and other AWS-compatible services).
+// In-memory bodies are hashed, streamed bodies (BodyStream)
+// are sent with UNSIGNED-PAYLOAD for S3 (other services require hashes)
+type AWSSigner struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken of temporary credentials (optional)
+	SessionToken string
+
+	Region  string
+	Service string
+
+	// UnsignedPayload: don't hash bodies (S3 only)
+	UnsignedPayload bool
+
+	// now is used in tests
+	now func() time.Time
+}
+
+// Authenticate implements Auth
+func (s *AWSSigner) Authenticate(request *http.Request) error {
+	t := s.time()
+	payloadHash := awsUnsignedPayload
+	if !s.UnsignedPayload {
+		body, err := requestBody(request)
+		if err == nil {
+			sum := sha256.Sum256(body)
+			payloadHash = hex.EncodeToString(sum[:])
+		} else if s.Service != "s3" {
+			return err
+		}
+	}
+
+	request.Header.Set("X-Amz-Date", t.Format(awsTimeFormat))
+	if s.SessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+	if s.Service == "s3" {
+		request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	// the host is signed as sent, so the default port is removed
+	// from the sent Host header too (like AWS SDKs do)
+	request.Host = requestHost(request)
+	headers := map[string]string{"host": request.Host}
+	for k, vs := range request.Header {
+		k = strings.ToLower(k)
+		if awsSkipHeaders[k] || k == "host" {
+			continue
+		}
+		values := make([]string, len(vs))
+		for i, v := range vs {
+			values[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[k] = strings.Join(values, ",")
+	}
+
+	signedHeaders, signature := s.sign(request.Method, request.URL, request.URL.RawQuery,
+		headers, payloadHash, t)
+	request.Header.Set("Authorization", awsAlgorithm+
+		" Credential="+s.AccessKeyID+"/"+s.scope(t)+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+signature)
+	return nil
+}
+
+// Presign returns presigned URL of r valid for expires
+// (signed host header and UNSIGNED-PAYLOAD),
+// the URL is built from r fields like for Send
+func (s *AWSSigner) Presign(r *Req, expires time.Duration) (string, error) {
+	// only URL and Host header, the body isn't used
+	path, err := expandPath(r.Path, r.PathParams)
+	if err != nil {
+		return "", errow.Wrap(err, "bad path")
+	}
+	fullURL, err := buildFullURL(r.URL, path, r.Params, r.ParamsStyle)
+	if err != nil {
+		return "", errow.Wrap(err, "bad full url")
+	}
+	u, err := url.Parse(fullURL)
+	if err != nil {
+		return "", errow.Wrap(err, "bad full url")
+	}
+	request := &http.Request{Method: r.Method, URL: u, Header: http.Header{}}
+	if request.Method == "" {
+		request.Method = http.MethodGet
+	}
+	setHeaders(request, r.Headers, false)
+
+	// the host is signed as sent, so the default port is removed
+	// from the URL unless Host header is set
+	if request.Host == "" {
+		u.Host = requestHost(request)
+	}
+
+	t := s.time()
+	query := u.RawQuery
+	for _, p := range [][2]string{
+		{"X-Amz-Algorithm", awsAlgorithm},
+		{"X-Amz-Credential", s.AccessKeyID + "/" + s.scope(t)},
+		{"X-Amz-Date", t.Format(awsTimeFormat)},
+		{"X-Amz-Expires", strconv.Itoa(int(expires.Seconds()))},
+		{"X-Amz-SignedHeaders", "host"},
+	} {
+		query = appendQuery(query, p[0], p[1])
+	}
+	if s.SessionToken != "" {
+		query = appendQuery(query, "X-Amz-Security-Token", s.SessionToken)
+	}
+
+	_, signature := s.sign(request.Method, u, query,
+		map[string]string{"host": requestHost(request)}, awsUnsignedPayload, t)
+	presigned := *u
+	presigned.RawQuery = appendQuery(query, "X-Amz-Signature", signature)
+	return presigned.String(), nil
+}
+
+// sign returns signed headers and signature of canonical request
+func (s *AWSSigner) sign(method string, u *url.URL, rawQuery string,
+	headers map[string]string, payloadHash string, t time.Time) (string, string) {
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + strings.TrimSpace(headers[k]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		s.canonicalPath(u),
+		awsCanonicalQuery(rawQuery),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		awsAlgorithm,
+		t.Format(awsTimeFormat),
+		s.scope(t),
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := []byte("AWS4" + s.SecretAccessKey)
+	for _, part := range []string{t.Format("20060102"), s.Region, s.Service, "aws4_request", stringToSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	return signedHeaders, hex.EncodeToString(key)
+}
+
+// scope returns credential scope
+func (s *AWSSigner) scope(t time.Time) string {
+	return t.Format("20060102") + "/" + s.Region + "/" + s.Service + "/aws4_request"
+}
+
+// time returns current UTC time
+func (s *AWSSigner) time() time.Time {
+	if s.now != nil {
+		return s.now().UTC()
+	}
+	return time.Now().UTC()
+}
+
+// canonicalPath returns URI-encoded path segments,
+// they are encoded twice for services other than S3
+func (s *AWSSigner) canonicalPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if unescaped, err := url.PathUnescape(seg); err == nil {
+			seg = unescaped
+		}
+		seg = escapeRFC3986(seg)
+		if s.Service != "s3" {
+			seg = escapeRFC3986(seg)
+		}
+		segments[i] = seg
+	}
+	return strings.Join(segments, "/")
+}
+
+// awsCanonicalQuery returns URI-encoded params sorted by name and value
+func awsCanonicalQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	var params [][2]string
+	for _, p := range strings.Split(rawQuery, "&") {
+		if p == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(p, "=")
+		if unescaped, err := url.QueryUnescape(k); err == nil {
+			k = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(v); err == nil {
+			v = unescaped
+		}
+		params = append(params, [2]string{escapeRFC3986(k), escapeRFC3986(v)})
+	}
+	return joinSortedPairs(params)
+}
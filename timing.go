@@ -0,0 +1,120 @@
+package req
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing is a breakdown of single attempt durations
+// collected via net/http/httptrace.
+// DNS, Connect and TLSHandshake are zero for reused connections
+type Timing struct {
+	// DNS lookup duration
+	DNS time.Duration
+	// Connect: TCP connection duration
+	Connect time.Duration
+	// TLSHandshake duration
+	TLSHandshake time.Duration
+	// TTFB: time to the first response byte from the attempt start
+	TTFB time.Duration
+	// BodyRead: duration of response body reading
+	BodyRead time.Duration
+	// Total duration of the attempt
+	Total time.Duration
+
+	// ConnReused: the connection was used for previous requests
+	ConnReused bool
+	// ConnWasIdle: the reused connection was idle
+	ConnWasIdle bool
+	// ConnIdleTime: how long the reused connection was idle
+	ConnIdleTime time.Duration
+	// RemoteAddr is the address of the server (or proxy)
+	RemoteAddr string
+}
+
+// timingTrace collects Timing of an attempt.
+// Hooks are called from transport goroutines
+type timingTrace struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	timing       Timing
+}
+
+// startTiming returns request with tracing hooks
+func startTiming(request *http.Request) (*http.Request, *timingTrace) {
+	tt := &timingTrace{start: time.Now()}
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			tt.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			tt.timing.DNS = time.Since(tt.dnsStart)
+		},
+		ConnectStart: func(string, string) {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			// several addresses can be dialed in parallel, count from the first
+			if tt.connectStart.IsZero() {
+				tt.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(_, _ string, err error) {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			if err == nil {
+				tt.timing.Connect = time.Since(tt.connectStart)
+			}
+		},
+		TLSHandshakeStart: func() {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			tt.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			tt.timing.TLSHandshake = time.Since(tt.tlsStart)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			tt.timing.ConnReused = info.Reused
+			tt.timing.ConnWasIdle = info.WasIdle
+			tt.timing.ConnIdleTime = info.IdleTime
+			if info.Conn != nil {
+				tt.timing.RemoteAddr = info.Conn.RemoteAddr().String()
+			}
+		},
+		GotFirstResponseByte: func() {
+			tt.mu.Lock()
+			defer tt.mu.Unlock()
+			tt.timing.TTFB = time.Since(tt.start)
+		},
+	}
+	return request.WithContext(httptrace.WithClientTrace(request.Context(), trace)), tt
+}
+
+// setBodyRead records duration of response body reading
+func (tt *timingTrace) setBodyRead(d time.Duration) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	tt.timing.BodyRead = d
+}
+
+// finish returns collected Timing with Total duration
+func (tt *timingTrace) finish() Timing {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	tt.timing.Total = time.Since(tt.start)
+	return tt.timing
+}
@@ -0,0 +1,48 @@
+package req
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReqSend_Timing(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	r := New(srv.URL).WithClient(srv.Client())
+	resp, err := r.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	timing := resp.Timing()
+	if timing.Connect <= 0 || timing.TLSHandshake <= 0 || timing.ConnReused {
+		t.Fatal("Unexpected timing of new connection:", timing)
+	}
+	if timing.TTFB < 20*time.Millisecond || timing.Total < timing.TTFB ||
+		resp.History[0].Duration != timing.Total {
+		t.Fatal("Unexpected timing:", timing)
+	}
+	if timing.RemoteAddr != srv.Listener.Addr().String() {
+		t.Fatal("Unexpected remote addr:", timing.RemoteAddr)
+	}
+
+	resp, err = r.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	timing = resp.Timing()
+	if !timing.ConnReused || timing.TLSHandshake != 0 || timing.Connect != 0 {
+		t.Fatal("Unexpected timing of reused connection:", timing)
+	}
+}
+
+func TestResp_TimingEmpty(t *testing.T) {
+	if timing := (&Resp{}).Timing(); timing != (Timing{}) {
+		t.Fatal("Unexpected timing:", timing)
+	}
+}
@@ -0,0 +1,34 @@
+package req
+
+import (
+	"context"
+	"net/http"
+)
+
+// Tracer instruments Send with spans: one span of the logical request
+// and child spans of its attempts.
+// Set it per Req (Req.Tracer) or per Session (Session.Tracer).
+// See github.com/nordborn/go-req/reqotel module for OpenTelemetry adapter
+type Tracer interface {
+	// StartRequest is called once per Send with Req.Context.
+	// The returned context is used for attempts and logs
+	StartRequest(ctx context.Context, r *Req) (context.Context, RequestSpan)
+}
+
+// RequestSpan is a span of the logical request
+type RequestSpan interface {
+	// StartAttempt is called before each attempt with the built request.
+	// It can inject headers (traceparent, baggage) and returns
+	// the request to send (usually with the attempt span in its context)
+	StartAttempt(ctx context.Context, attempt int, request *http.Request) (*http.Request, AttemptSpan)
+
+	// End is called after the last attempt with the result of Send
+	End(resp *Resp, err error)
+}
+
+// AttemptSpan is a span of single attempt
+type AttemptSpan interface {
+	// End is called after the attempt. Err is the transport error (if any),
+	// retry is one of RetryReason... if the next attempt follows, otherwise ""
+	End(info AttemptInfo, respRaw *http.Response, err error, retry string)
+}
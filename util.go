@@ -2,16 +2,18 @@ package req
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/nordborn/go-errow"
-	"github.com/nordborn/golog"
 )
 
-func buildFullURL(base, path string, getParams Vals) (string, error) {
+func buildFullURL(base, path string, getParams Vals, style EncodeStyle) (string, error) {
 	baseURL, err := url.Parse(base)
 	if err != nil {
 		return "", errow.Wrap(err)
@@ -24,16 +26,65 @@ func buildFullURL(base, path string, getParams Vals) (string, error) {
 	reqURL := baseURL.ResolveReference(pathURL)
 	// redefine get params if provided OR use get params from 'path'
 	if getParams != nil {
-		reqURL.RawQuery = getParams.URLEncode()
+		reqURL.RawQuery = getParams.URLEncodeStyle(style)
 	}
 
 	return reqURL.String(), nil
 }
 
+// expandPath replaces {name} placeholders of the path template
+// with path-escaped values of params.
+// Each placeholder must have a param and each param must be used,
+// values can't be empty or dot segments ("." and "..")
+func expandPath(template string, params Vals) (string, error) {
+	if !strings.Contains(template, "{") {
+		if len(params) > 0 {
+			return "", errow.Newf("path params %v without placeholders in path %q", params, template)
+		}
+		return template, nil
+	}
+
+	var (
+		b    strings.Builder
+		used = make(map[string]bool, len(params))
+		rest = template
+	)
+	for {
+		start := strings.Index(rest, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			return "", errow.Newf("unclosed placeholder in path %q", template)
+		}
+		end += start
+		name := rest[start+1 : end]
+		if !params.Has(name) {
+			return "", errow.Newf("missing path param %q for path %q", name, template)
+		}
+		value := fmt.Sprint(params.Get(name))
+		if value == "" || value == "." || value == ".." {
+			return "", errow.Newf("bad value %q of path param %q", value, name)
+		}
+		used[name] = true
+		b.WriteString(rest[:start])
+		b.WriteString(url.PathEscape(value))
+		rest = rest[end+1:]
+	}
+	b.WriteString(rest)
+
+	for _, p := range params {
+		if !used[p.K] {
+			return "", errow.Newf("unused path param %q for path %q", p.K, template)
+		}
+	}
+	return b.String(), nil
+}
+
 func shouldRetryOnStatusCode(statusCode int, retryOnCodes [][2]int) bool {
 	for _, codesPair := range retryOnCodes {
 		if statusCode >= codesPair[0] && statusCode <= codesPair[1] {
-			golog.Tracef("found matched code %v in %v\n", statusCode, codesPair)
 			return true
 		}
 	}
@@ -49,16 +100,39 @@ func shouldRetryOnTextMarker(content []byte, repeatOnTextMarkers []string) bool
 	return false
 }
 
-func delay(delayMillis int) {
-	if delayMillis > 0 {
-		<-time.After(time.Duration(delayMillis) * time.Millisecond)
+// delay waits delayMillis or until ctx is done
+func delay(ctx context.Context, delayMillis int) {
+	if delayMillis <= 0 {
+		return
+	}
+	t := time.NewTimer(time.Duration(delayMillis) * time.Millisecond)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
 	}
 }
 
-// setHeaders modifies request: it sets headers
-func setHeaders(request *http.Request, headers Vals) {
+// setHeaders modifies request: it sets headers.
+// If add is true, then values are added to existing values of the header.
+// Slice values are added as multiple values of the header,
+// "Host" header sets request.Host
+func setHeaders(request *http.Request, headers Vals, add bool) {
 	for _, v := range headers {
-		request.Header.Set(v.K, fmt.Sprint(v.V))
+		if strings.EqualFold(v.K, "Host") {
+			request.Host = fmt.Sprint(v.V)
+			continue
+		}
+		items, isSeq := seqItems(v.V)
+		if !isSeq {
+			items = []any{v.V}
+		}
+		if !add {
+			request.Header.Del(v.K)
+		}
+		for _, item := range items {
+			request.Header.Add(v.K, fmt.Sprint(item))
+		}
 	}
 }
 
@@ -68,3 +142,62 @@ func setCookies(request *http.Request, cookies []*http.Cookie) {
 		request.AddCookie(c)
 	}
 }
+
+// appendQuery appends escaped param to raw query keeping the order
+// (nothing if name is empty)
+func appendQuery(query, name, value string) string {
+	if name == "" {
+		return query
+	}
+	if query != "" {
+		query += "&"
+	}
+	return query + url.QueryEscape(name) + "=" + url.QueryEscape(value)
+}
+
+// joinSortedPairs sorts encoded params by name and value
+// and joins them as query string, used by signers
+func joinSortedPairs(pairs [][2]string) string {
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	joined := make([]string, len(pairs))
+	for i, p := range pairs {
+		joined[i] = p[0] + "=" + p[1]
+	}
+	return strings.Join(joined, "&")
+}
+
+// escapeRFC3986 encodes all chars except unreserved ones (RFC 3986),
+// used by signers
+func escapeRFC3986(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexDigits[c>>4])
+		b.WriteByte(hexDigits[c&15])
+	}
+	return b.String()
+}
+
+// requestHost returns request host without default port
+func requestHost(request *http.Request) string {
+	host := request.Host
+	if host == "" {
+		host = request.URL.Host
+	}
+	if request.URL.Scheme == "https" {
+		return strings.TrimSuffix(host, ":443")
+	}
+	return strings.TrimSuffix(host, ":80")
+}
@@ -1,12 +1,15 @@
 package req
 
-import "testing"
+import (
+	"net/http/httptest"
+	"testing"
+)
 
 func Test_buildFullURL(t *testing.T) {
 	base := "http://httpbin.org"
 	path := "get"
 	params := Vals{{"c", "d"}}
-	fullURL, err := buildFullURL(base, path, params)
+	fullURL, err := buildFullURL(base, path, params, StylePlain)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -15,3 +18,75 @@ func Test_buildFullURL(t *testing.T) {
 	}
 	t.Log(fullURL)
 }
+
+func Test_buildFullURL_Style(t *testing.T) {
+	params := Vals{{"ids", []int{1, 2}}}
+	fullURL, err := buildFullURL("http://httpbin.org", "get", params, StyleBrackets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fullURL != "http://httpbin.org/get?ids[]=1&ids[]=2" {
+		t.Fatal(fullURL)
+	}
+}
+
+func Test_setHeaders(t *testing.T) {
+	headers := Vals{
+		{"Accept", "text/plain"},
+		{"X-Multi", []string{"a", "b"}},
+		{"accept", "application/json"},
+		{"host", "example.com"},
+	}
+
+	request := httptest.NewRequest("GET", "http://httpbin.org/get", nil)
+	setHeaders(request, headers, false)
+	if v := request.Header.Values("Accept"); len(v) != 1 || v[0] != "application/json" {
+		t.Fatal("Unexpected Accept:", v)
+	}
+	if v := request.Header.Values("X-Multi"); len(v) != 2 || v[1] != "b" {
+		t.Fatal("Unexpected X-Multi:", v)
+	}
+	if request.Host != "example.com" || request.Header.Get("Host") != "" {
+		t.Fatal("Unexpected Host:", request.Host)
+	}
+
+	request = httptest.NewRequest("GET", "http://httpbin.org/get", nil)
+	setHeaders(request, headers, true)
+	if v := request.Header.Values("Accept"); len(v) != 2 || v[0] != "text/plain" {
+		t.Fatal("Unexpected Accept:", v)
+	}
+}
+
+func Test_expandPath(t *testing.T) {
+	path, err := expandPath("/users/{id}/orders/{orderID}", Vals{{"orderID", "a/b?c"}, {"id", 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/users/1/orders/a%2Fb%3Fc" {
+		t.Fatal("Unexpected path:", path)
+	}
+	fullURL, err := buildFullURL("http://httpbin.org", path, Vals{{"a", "b"}}, StylePlain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fullURL != "http://httpbin.org/users/1/orders/a%2Fb%3Fc?a=b" {
+		t.Fatal("Unexpected full url:", fullURL)
+	}
+
+	badCases := []struct {
+		template string
+		params   Vals
+	}{
+		{"/users/{id}", nil},
+		{"/users/{id}", Vals{{"id", ".."}}},
+		{"/users/{id}", Vals{{"id", ""}}},
+		{"/users/{id", Vals{{"id", 1}}},
+		{"/users/{id}", Vals{{"id", 1}, {"other", 2}}},
+		{"/users", Vals{{"id", 1}}},
+	}
+	for _, c := range badCases {
+		if _, err := expandPath(c.template, c.params); err == nil {
+			t.Error("Expected err, but got nil for", c)
+		}
+	}
+}
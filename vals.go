@@ -1,9 +1,13 @@
 package req
 
 import (
+	"bytes"
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
+
+	"github.com/nordborn/go-errow"
 )
 
 // val represents single key:value pair
@@ -20,15 +24,13 @@ func hasSeqSig(s string) bool {
 		(strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]"))
 }
 
+// String returns "key":value JSON representation of the val
 func (v val) String() string {
-	if val, ok := v.V.(string); ok {
-		if hasSeqSig(val) {
-			return fmt.Sprintf(`"%s":%s`, v.K, val)
-		} else {
-			return fmt.Sprintf(`"%v":"%v"`, v.K, val)
-		}
+	var buf bytes.Buffer
+	if err := v.writeJSON(&buf); err != nil {
+		return fmt.Sprintf(`"%v":%v`, v.K, v.V)
 	}
-	return fmt.Sprintf(`"%v":%v`, v.K, v.V)
+	return buf.String()
 }
 
 // Vals is a slice of *val.
@@ -40,35 +42,28 @@ func (v val) String() string {
 // We use it of url.Values to keep ordering of parameters
 type Vals []val
 
-// URLEncode similar to net/url.Encode for url.Values, but for Vals
-func (vals Vals) URLEncode() (urlEncoded string) {
-	for i, param := range vals {
-		enc := url.QueryEscape(param.K) + "=" + url.QueryEscape(fmt.Sprint(param.V))
-		if i == 0 {
-			urlEncoded = enc
-		} else {
-			urlEncoded += "&" + enc
-		}
-	}
-	return urlEncoded
+// URLEncode similar to net/url.Encode for url.Values, but for Vals.
+// Values are encoded with fmt.Sprint (see URLEncodeStyle to expand
+// slices, maps and nested Vals)
+func (vals Vals) URLEncode() string {
+	return vals.URLEncodeStyle(StylePlain)
 }
 
 // JSON method returns _ORDERED_ map (in order of vals appearance) of Vals as JSON string
 // like {"v.K": "v.V", ...}.
-// Use it for simple cases when v.K and v.V can be correctly
-// converted just with fmt.Sprintf(`"%s":"%s"`, v.K, v.V),
-// or with fmt.Sprintf(`"%s":%s`, v.K, v.V) if v.V like "{...}" or "[...]".
-// In other cases use json.Marshal
+// Keys and values are properly escaped, nested Vals and []any
+// are encoded natively, other values are encoded with encoding/json.
+// String values are always encoded as JSON strings,
+// use json.RawMessage to embed pre-encoded JSON:
+// req.Vals{{"name", json.RawMessage(`[1,2]`)}}.JSON() // => {"name":[1,2]}
+// Note: it returns empty string if a value can't be encoded
+// (like NaN or channel), use MarshalJSON to get the error
 func (vals Vals) JSON() string {
-	s := "{"
-	for i, v := range vals {
-		if i > 0 {
-			s += ","
-		}
-		s += v.String()
+	b, err := vals.MarshalJSON()
+	if err != nil {
+		return ""
 	}
-	s += "}"
-	return s
+	return string(b)
 }
 
 // Extend adds more Vals to existing Vals
@@ -76,3 +71,139 @@ func (vals Vals) JSON() string {
 func (vals Vals) Extend(more Vals) Vals {
 	return append(vals, more...)
 }
+
+// ParseVals parses URL-encoded query string or form body
+// ("n1=v1&n2=v2", leading "?" is allowed) into Vals
+// keeping order of parameters.
+// Similar to net/url.ParseQuery, it returns the first
+// decoding error (if any) with all parameters that were parsed
+func ParseVals(query string) (Vals, error) {
+	var (
+		vals     Vals
+		firstErr error
+	)
+	query = strings.TrimPrefix(query, "?")
+	for query != "" {
+		var param string
+		param, query, _ = strings.Cut(query, "&")
+		if strings.Contains(param, ";") {
+			if firstErr == nil {
+				firstErr = errow.New("invalid semicolon separator in query")
+			}
+			continue
+		}
+		if param == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(param, "=")
+		k, err := url.QueryUnescape(k)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = errow.Wrap(err)
+			}
+			continue
+		}
+		v, err = url.QueryUnescape(v)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = errow.Wrap(err)
+			}
+			continue
+		}
+		vals = append(vals, val{k, v})
+	}
+	return vals, firstErr
+}
+
+// FromURLValues returns new Vals extended with values of url.Values.
+// url.Values is a map, so keys are added in sorted order
+// (values of the same key keep their order)
+func (vals Vals) FromURLValues(values url.Values) Vals {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range values[k] {
+			vals = append(vals, val{k, v})
+		}
+	}
+	return vals
+}
+
+// ToURLValues converts Vals to url.Values
+// (values are converted to strings with fmt.Sprint)
+func (vals Vals) ToURLValues() url.Values {
+	values := make(url.Values, len(vals))
+	for _, v := range vals {
+		values.Add(v.K, fmt.Sprint(v.V))
+	}
+	return values
+}
+
+// Index returns index of the first val with the key or -1
+func (vals Vals) Index(key string) int {
+	for i, v := range vals {
+		if v.K == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Has checks whether the key exists
+func (vals Vals) Has(key string) bool {
+	return vals.Index(key) >= 0
+}
+
+// Get returns the first value associated with the key
+// or nil if there are no values
+func (vals Vals) Get(key string) any {
+	if i := vals.Index(key); i >= 0 {
+		return vals[i].V
+	}
+	return nil
+}
+
+// GetAll returns all values associated with the key in order of appearance
+func (vals Vals) GetAll(key string) []any {
+	var values []any
+	for _, v := range vals {
+		if v.K == key {
+			values = append(values, v.V)
+		}
+	}
+	return values
+}
+
+// Add appends the value to the key
+func (vals *Vals) Add(key string, value any) {
+	*vals = append(*vals, val{key, value})
+}
+
+// Set sets the key to the value: it replaces the first existing value
+// keeping its position and deletes other values of the key,
+// or appends the value if there is no such key
+func (vals *Vals) Set(key string, value any) {
+	i := vals.Index(key)
+	if i < 0 {
+		vals.Add(key, value)
+		return
+	}
+	(*vals)[i].V = value
+	rest := (*vals)[i+1:]
+	rest.Del(key)
+	*vals = append((*vals)[:i+1], rest...)
+}
+
+// Del deletes all values associated with the key
+func (vals *Vals) Del(key string) {
+	kept := (*vals)[:0]
+	for _, v := range *vals {
+		if v.K != key {
+			kept = append(kept, v)
+		}
+	}
+	*vals = kept
+}
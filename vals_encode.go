@@ -0,0 +1,162 @@
+package req
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EncodeStyle defines how slices, maps and nested Vals are expanded
+// by Vals.URLEncodeStyle (see Req.ParamsStyle and Req.FormStyle).
+// Examples are for Vals{{"a", []int{1, 2}}, {"u", Vals{{"name", "x"}}}}
+type EncodeStyle int
+
+const (
+	// StylePlain encodes values with fmt.Sprint (default):
+	// a=%5B1+2%5D&u=%5B%22name%22%3A%22x%22%5D
+	StylePlain EncodeStyle = iota
+	// StyleRepeat repeats the key for each item and puts
+	// nested keys at the top level (OpenAPI form, explode=true):
+	// a=1&a=2&name=x
+	StyleRepeat
+	// StyleBrackets is Rails/PHP style: a[]=1&a[]=2&u[name]=x
+	StyleBrackets
+	// StyleIndices is PHP http_build_query style: a[0]=1&a[1]=2&u[name]=x
+	StyleIndices
+	// StyleDeepObject is OpenAPI deepObject style
+	// (items are repeated): a=1&a=2&u[name]=x
+	StyleDeepObject
+	// StyleComma is OpenAPI form, explode=false: a=1,2&u=name,x
+	// (nested slices and objects are flattened in delimited styles)
+	StyleComma
+	// StyleSpaceDelimited is OpenAPI spaceDelimited: a=1%202&u=name%20x
+	StyleSpaceDelimited
+	// StylePipeDelimited is OpenAPI pipeDelimited: a=1|2&u=name|x
+	StylePipeDelimited
+)
+
+// URLEncodeStyle is like URLEncode, but slices, arrays, maps
+// (sorted by keys) and nested Vals are expanded according to the style.
+// Brackets and delimiters are not escaped
+func (vals Vals) URLEncodeStyle(style EncodeStyle) string {
+	var pairs []string
+	for _, v := range vals {
+		pairs = appendStyled(pairs, url.QueryEscape(v.K), v.V, style)
+	}
+	return strings.Join(pairs, "&")
+}
+
+// appendStyled appends escaped "key=value" pairs of the value to pairs
+func appendStyled(pairs []string, key string, value any, style EncodeStyle) []string {
+	if style == StylePlain {
+		return append(pairs, key+"="+url.QueryEscape(fmt.Sprint(value)))
+	}
+
+	switch style {
+	case StyleComma, StyleSpaceDelimited, StylePipeDelimited:
+		return append(pairs, key+"="+strings.Join(delimitedParts(nil, value), styleSep(style)))
+	}
+
+	if items, ok := seqItems(value); ok {
+		for i, item := range items {
+			itemKey := key
+			switch style {
+			case StyleBrackets:
+				itemKey += "[]"
+			case StyleIndices:
+				itemKey += "[" + strconv.Itoa(i) + "]"
+			}
+			pairs = appendStyled(pairs, itemKey, item, style)
+		}
+		return pairs
+	}
+
+	if members, ok := objectMembers(value); ok {
+		for _, m := range members {
+			memberKey := url.QueryEscape(m.K)
+			if style != StyleRepeat {
+				memberKey = key + "[" + memberKey + "]"
+			}
+			pairs = appendStyled(pairs, memberKey, m.V, style)
+		}
+		return pairs
+	}
+
+	return append(pairs, key+"="+url.QueryEscape(fmt.Sprint(value)))
+}
+
+// delimitedParts appends escaped parts of the value for delimited styles:
+// items of sequences and keys and values of objects are flattened
+func delimitedParts(parts []string, value any) []string {
+	if items, ok := seqItems(value); ok {
+		for _, item := range items {
+			parts = delimitedParts(parts, item)
+		}
+		return parts
+	}
+	if members, ok := objectMembers(value); ok {
+		for _, m := range members {
+			parts = delimitedParts(append(parts, url.QueryEscape(m.K)), m.V)
+		}
+		return parts
+	}
+	return append(parts, url.QueryEscape(fmt.Sprint(value)))
+}
+
+// styleSep returns delimiter of delimited styles
+func styleSep(style EncodeStyle) string {
+	switch style {
+	case StyleSpaceDelimited:
+		return "%20"
+	case StylePipeDelimited:
+		return "|"
+	}
+	return ","
+}
+
+// seqItems returns items of slice or array value
+// ([]byte is not a sequence, it's a value)
+func seqItems(value any) ([]any, bool) {
+	switch value := value.(type) {
+	case []any:
+		return value, true
+	case []byte, Vals:
+		return nil, false
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	items := make([]any, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items, true
+}
+
+// objectMembers returns ordered members of Vals, val or map value
+// (map keys are sorted)
+func objectMembers(value any) (Vals, bool) {
+	switch value := value.(type) {
+	case Vals:
+		return value, true
+	case val:
+		return Vals{value}, true
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Map {
+		return nil, false
+	}
+	members := make(Vals, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		members = append(members, val{fmt.Sprint(iter.Key().Interface()), iter.Value().Interface()})
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		return members[i].K < members[j].K
+	})
+	return members, true
+}
@@ -0,0 +1,36 @@
+package req
+
+import "testing"
+
+func TestVals_URLEncodeStyle(t *testing.T) {
+	v := Vals{
+		{"a", []int{1, 2}},
+		{"u", Vals{{"name", "x y"}, {"tags", []string{"t1", "t2"}}}},
+		{"m", map[string]any{"b": 2, "a": 1}},
+		{"s", "v"},
+	}
+	assertions := []struct {
+		style    EncodeStyle
+		expected string
+	}{
+		{StyleRepeat, "a=1&a=2&name=x+y&tags=t1&tags=t2&a=1&b=2&s=v"},
+		{StyleBrackets, "a[]=1&a[]=2&u[name]=x+y&u[tags][]=t1&u[tags][]=t2&m[a]=1&m[b]=2&s=v"},
+		{StyleIndices, "a[0]=1&a[1]=2&u[name]=x+y&u[tags][0]=t1&u[tags][1]=t2&m[a]=1&m[b]=2&s=v"},
+		{StyleDeepObject, "a=1&a=2&u[name]=x+y&u[tags]=t1&u[tags]=t2&m[a]=1&m[b]=2&s=v"},
+		{StyleComma, "a=1,2&u=name,x+y,tags,t1,t2&m=a,1,b,2&s=v"},
+		{StyleSpaceDelimited, "a=1%202&u=name%20x+y%20tags%20t1%20t2&m=a%201%20b%202&s=v"},
+		{StylePipeDelimited, "a=1|2&u=name|x+y|tags|t1|t2&m=a|1|b|2&s=v"},
+	}
+	for _, a := range assertions {
+		if s := v.URLEncodeStyle(a.style); s != a.expected {
+			t.Errorf("Unexpected encoding for style %v: %v\n", a.style, s)
+		}
+	}
+}
+
+func TestVals_URLEncodeStylePlain(t *testing.T) {
+	v := Vals{{"a", []int{1, 2}}, {"b", []byte("x")}}
+	if s := v.URLEncodeStyle(StylePlain); s != v.URLEncode() || s != "a=%5B1+2%5D&b=%5B120%5D" {
+		t.Fatal("Unexpected encoding:", s)
+	}
+}
@@ -0,0 +1,184 @@
+package req
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/nordborn/go-errow"
+)
+
+// MarshalJSON implements json.Marshaler keeping order of Vals,
+// so Vals can be embedded into other structures:
+// json.Marshal(struct{ Data req.Vals }{req.Vals{{"n1", "v1"}}})
+func (vals Vals) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := vals.writeJSON(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeJSON writes vals as JSON object to buf
+func (vals Vals) writeJSON(buf *bytes.Buffer) error {
+	if vals == nil {
+		buf.WriteString("null")
+		return nil
+	}
+	buf.WriteByte('{')
+	for i, v := range vals {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := v.writeJSON(buf); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// writeJSON writes "key":value pair to buf
+func (v val) writeJSON(buf *bytes.Buffer) error {
+	if err := writeJSONValue(buf, v.K); err != nil {
+		return err
+	}
+	buf.WriteByte(':')
+	if err := writeJSONValue(buf, v.V); err != nil {
+		return errow.Wrapf(err, "bad value of %q", v.K)
+	}
+	return nil
+}
+
+// writeJSONValue writes JSON representation of any value to buf.
+// Vals, val and []any are encoded natively to keep order of nested Vals,
+// strings are always JSON strings (use json.RawMessage to embed JSON)
+func writeJSONValue(buf *bytes.Buffer, value any) error {
+	switch value := value.(type) {
+	case nil:
+		buf.WriteString("null")
+		return nil
+	case Vals:
+		return value.writeJSON(buf)
+	case val:
+		buf.WriteByte('{')
+		if err := value.writeJSON(buf); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+		return nil
+	case []any:
+		if value == nil {
+			buf.WriteString("null")
+			return nil
+		}
+		buf.WriteByte('[')
+		for i, item := range value {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONValue(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	}
+
+	// other values (strings, json.RawMessage, ...) via encoding/json,
+	// without HTML escaping to keep values readable as is
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return errow.Wrap(err)
+	}
+	// Encode adds newline
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler: it decodes JSON object
+// into Vals keeping order of keys.
+// Nested objects are decoded as Vals, arrays as []any,
+// numbers as json.Number (to keep them exactly as they were),
+// strings, bools and nulls as string, bool and nil
+func (vals *Vals) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return errow.Wrap(err)
+	}
+	var obj Vals
+	switch tok {
+	case nil:
+	case json.Delim('{'):
+		if obj, err = decodeJSONObject(dec); err != nil {
+			return err
+		}
+	default:
+		return errow.Newf("can't unmarshal %v to Vals: not a JSON object", tok)
+	}
+	if _, err = dec.Token(); err != io.EOF {
+		return errow.New("can't unmarshal to Vals: unexpected data after JSON object")
+	}
+	*vals = obj
+	return nil
+}
+
+// decodeJSONObject decodes object members after opening '{'
+func decodeJSONObject(dec *json.Decoder) (Vals, error) {
+	obj := Vals{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, errow.Wrap(err)
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, errow.Newf("bad object key %v", tok)
+		}
+		value, err := decodeJSONValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		obj = append(obj, val{key, value})
+	}
+	// closing '}'
+	if _, err := dec.Token(); err != nil {
+		return nil, errow.Wrap(err)
+	}
+	return obj, nil
+}
+
+// decodeJSONArray decodes array items after opening '['
+func decodeJSONArray(dec *json.Decoder) ([]any, error) {
+	arr := []any{}
+	for dec.More() {
+		value, err := decodeJSONValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, value)
+	}
+	// closing ']'
+	if _, err := dec.Token(); err != nil {
+		return nil, errow.Wrap(err)
+	}
+	return arr, nil
+}
+
+// decodeJSONValue decodes next value of any kind
+func decodeJSONValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, errow.Wrap(err)
+	}
+	switch tok {
+	case json.Delim('{'):
+		return decodeJSONObject(dec)
+	case json.Delim('['):
+		return decodeJSONArray(dec)
+	}
+	return tok, nil
+}
@@ -0,0 +1,115 @@
+package req
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestVals_JSONEscape(t *testing.T) {
+	v := Vals{{`n"1`, "v\"1\\\n"}, {"<n2>", "a&b"}, {"n3", "[not json"}}
+	s := v.JSON()
+	if s != `{"n\"1":"v\"1\\\n","<n2>":"a&b","n3":"[not json"}` {
+		t.Fatal("Unexpected v.JSON:", s)
+	}
+	if !json.Valid([]byte(s)) {
+		t.Fatal("Invalid JSON:", s)
+	}
+}
+
+func TestVals_JSONTypes(t *testing.T) {
+	v := Vals{
+		{"nil", nil},
+		{"bool", true},
+		{"float", 1.5},
+		{"struct", struct {
+			A int `json:"a"`
+		}{1}},
+		{"map", map[string]int{"k": 1}},
+	}
+	s := v.JSON()
+	if s != `{"nil":null,"bool":true,"float":1.5,"struct":{"a":1},"map":{"k":1}}` {
+		t.Fatal("Unexpected v.JSON:", s)
+	}
+}
+
+func TestVals_JSONNested(t *testing.T) {
+	v := Vals{
+		{"z", Vals{{"b", 1}, {"a", 2}}},
+		{"arr", []any{"x", Vals{{"d", 1}, {"c", 2}}, []any{1, 2}}},
+	}
+	s := v.JSON()
+	if s != `{"z":{"b":1,"a":2},"arr":["x",{"d":1,"c":2},[1,2]]}` {
+		t.Fatal("Unexpected v.JSON:", s)
+	}
+}
+
+func TestVals_JSONErr(t *testing.T) {
+	v := Vals{{"n", math.NaN()}}
+	if s := v.JSON(); s != "" {
+		t.Fatal("Unexpected v.JSON:", s)
+	}
+	if _, err := json.Marshal(v); err == nil {
+		t.Fatal("Expected err, but got nil")
+	}
+}
+
+func TestVals_MarshalJSON(t *testing.T) {
+	data := struct {
+		Name string `json:"name"`
+		Data Vals   `json:"data"`
+		Nil  Vals   `json:"nil"`
+	}{"test", Vals{{"n2", "v2"}, {"n1", "v1"}}, nil}
+	b, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"name":"test","data":{"n2":"v2","n1":"v1"},"nil":null}` {
+		t.Fatal("Unexpected json.Marshal:", string(b))
+	}
+}
+
+func TestVals_UnmarshalJSON(t *testing.T) {
+	data := `{"z":1.50,"a":"s","n":null,"b":true,"obj":{"y":1,"x":[1,{"d":1,"c":2}]},"e":{}}`
+	var v Vals
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v[0].K != "z" || v[0].V != json.Number("1.50") {
+		t.Fatal("Unexpected val:", v[0])
+	}
+	if _, ok := v[4].V.(Vals)[1].V.([]any)[1].(Vals); !ok {
+		t.Fatal("Unexpected nested val:", v[4])
+	}
+	// round-trip
+	if s := v.JSON(); s != data {
+		t.Fatal("Unexpected v.JSON:", s)
+	}
+}
+
+func TestVals_UnmarshalJSONErr(t *testing.T) {
+	for _, data := range []string{`[1,2]`, `"s"`, `{"a":`, `{"a":1`, `{"a":1} garbage`, `{"a":1}{}`, `null 1`} {
+		var v Vals
+		if err := v.UnmarshalJSON([]byte(data)); err == nil {
+			t.Fatal("Expected err, but got nil for", data)
+		}
+	}
+	var v Vals
+	if err := v.UnmarshalJSON([]byte(" {\"a\":1}\n")); err != nil || len(v) != 1 {
+		t.Fatal("Unexpected unmarshal with spaces:", v, err)
+	}
+	if err := json.Unmarshal([]byte(`null`), &v); err != nil || v != nil {
+		t.Fatal("Unexpected null unmarshal:", v, err)
+	}
+}
+
+func TestResp_Vals(t *testing.T) {
+	resp := Resp{Content: []byte(`{"b":1,"a":2}`)}
+	v, err := resp.Vals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.JSON() != `{"b":1,"a":2}` {
+		t.Fatal("Unexpected resp.Vals:", v)
+	}
+}
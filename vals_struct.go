@@ -0,0 +1,186 @@
+package req
+
+import (
+	"encoding"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/nordborn/go-errow"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// ValsFrom builds Vals from a struct (or a pointer to struct)
+// in order of its fields, so it can be used for Params, Form or Headers:
+//
+//	type Filter struct {
+//		Page    int       `url:"page"`
+//		Limit   int       `url:"limit,omitempty"`
+//		IDs     []int     `url:"id,omitempty"`
+//		Since   time.Time `url:"since,omitempty" layout:"2006-01-02"`
+//		Until   time.Time `url:"until,unix"`
+//		Secret  string    `url:"-"`
+//		Paging            // embedded struct fields are inlined
+//	}
+//	r.Params, err = req.ValsFrom(filter)
+//
+// Tag options:
+// "omitempty" skips zero values, empty slices and maps, nil pointers;
+// "unix", "unixmilli" encode time.Time as Unix seconds or milliseconds,
+// otherwise time.Time is formatted with `layout` tag (RFC3339 by default).
+// Fields without tag use field name, fields with "-" tag are skipped.
+// []byte is encoded as string, other slices keep their items as []any
+// and nested structs become nested Vals, so they are expanded according to Req.ParamsStyle or Req.FormStyle.
+// encoding.TextMarshaler values are encoded via MarshalText.
+// Nil pointer to struct gives nil Vals
+func ValsFrom(v any) (Vals, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, errow.Newf("can't build Vals from %T: not a struct", v)
+	}
+	return structVals(rv)
+}
+
+// structVals encodes fields of struct value
+func structVals(rv reflect.Value) (Vals, error) {
+	vals := Vals{}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := rv.Field(i)
+
+		// embedded struct without name: inline its fields
+		if field.Anonymous && name == "" {
+			for fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			// nil embedded struct pointer has no fields (like in encoding/json)
+			if fv.Kind() == reflect.Ptr && isStructType(fv.Type()) {
+				continue
+			}
+			if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+				embedded, err := structVals(fv)
+				if err != nil {
+					return nil, err
+				}
+				vals = append(vals, embedded...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if hasTagOpt(opts, "omitempty") && isEmptyValue(fv) {
+			continue
+		}
+		value, err := fieldValue(fv, field.Tag, opts)
+		if err != nil {
+			return nil, errow.Wrapf(err, "bad field %v", field.Name)
+		}
+		vals = append(vals, val{name, value})
+	}
+	return vals, nil
+}
+
+// isStructType checks if t is (pointer to) struct type except time.Time
+func isStructType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+// fieldValue converts field value to value of Vals
+func fieldValue(fv reflect.Value, tag reflect.StructTag, opts string) (any, error) {
+	for fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return "", nil
+		}
+		fv = fv.Elem()
+	}
+
+	if fv.Type() == timeType {
+		t := fv.Interface().(time.Time)
+		switch {
+		case hasTagOpt(opts, "unix"):
+			return t.Unix(), nil
+		case hasTagOpt(opts, "unixmilli"):
+			return t.UnixMilli(), nil
+		}
+		layout := tag.Get("layout")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return t.Format(layout), nil
+	}
+
+	if fv.Type().Implements(textMarshalerType) {
+		text, err := fv.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, errow.Wrap(err)
+		}
+		return string(text), nil
+	}
+
+	switch fv.Kind() {
+	case reflect.Struct:
+		return structVals(fv)
+	case reflect.Slice, reflect.Array:
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8 {
+			return string(fv.Bytes()), nil
+		}
+		items := make([]any, fv.Len())
+		for i := range items {
+			item, err := fieldValue(fv.Index(i), tag, opts)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	return fv.Interface(), nil
+}
+
+// hasTagOpt checks comma-separated tag options
+func hasTagOpt(opts, opt string) bool {
+	for opts != "" {
+		var o string
+		o, opts, _ = strings.Cut(opts, ",")
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmptyValue reports whether value should be omitted with omitempty
+func isEmptyValue(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return fv.Len() == 0
+	}
+	return fv.IsZero()
+}
@@ -0,0 +1,102 @@
+package req
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+type testPaging struct {
+	Page  int `url:"page"`
+	Limit int `url:"limit,omitempty"`
+}
+
+type testFilter struct {
+	Query   string    `url:"q"`
+	IDs     []int     `url:"id,omitempty"`
+	Tags    []string  `url:"tag,omitempty"`
+	Since   time.Time `url:"since,omitempty" layout:"2006-01-02"`
+	Until   time.Time `url:"until,unix"`
+	Created time.Time `url:"created"`
+	Active  *bool     `url:"active,omitempty"`
+	IP      net.IP    `url:"ip,omitempty"`
+	User    struct {
+		Name string `url:"name"`
+	} `url:"user"`
+	Secret string `url:"-"`
+	NoTag  string
+	hidden string
+	testPaging
+}
+
+func TestValsFrom(t *testing.T) {
+	active := true
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	f := testFilter{
+		Query:   "a b",
+		IDs:     []int{1, 2},
+		Until:   ts,
+		Created: ts,
+		Active:  &active,
+		IP:      net.ParseIP("127.0.0.1"),
+		Secret:  "secret",
+		NoTag:   "x",
+		hidden:  "hidden",
+	}
+	f.User.Name = "u"
+	f.Page = 3
+	v, err := ValsFrom(&f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "q=a+b&id[]=1&id[]=2&until=1577934245&created=2020-01-02T03%3A04%3A05Z" +
+		"&active=true&ip=127.0.0.1&user[name]=u&NoTag=x&page=3"
+	if s := v.URLEncodeStyle(StyleBrackets); s != expected {
+		t.Fatal("Unexpected vals:", s)
+	}
+
+	f.Since = ts
+	f.Limit = 10
+	v, err = ValsFrom(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Get("since") != "2020-01-02" || v.Get("limit") != 10 {
+		t.Fatal("Unexpected vals:", v)
+	}
+}
+
+type CursorPaging struct {
+	Cursor string `url:"cursor"`
+}
+
+func TestValsFrom_EmbeddedPtr(t *testing.T) {
+	type params struct {
+		Query string `url:"q"`
+		*CursorPaging
+	}
+	v, err := ValsFrom(params{Query: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.URLEncode(); s != "q=x" {
+		t.Fatal("Unexpected vals:", s)
+	}
+	v, err = ValsFrom(params{Query: "x", CursorPaging: &CursorPaging{Cursor: "c"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.URLEncode(); s != "q=x&cursor=c" {
+		t.Fatal("Unexpected vals:", s)
+	}
+}
+
+func TestValsFrom_Err(t *testing.T) {
+	if _, err := ValsFrom(1); err == nil {
+		t.Fatal("Expected err, but got nil")
+	}
+	v, err := ValsFrom((*testFilter)(nil))
+	if err != nil || v != nil {
+		t.Fatal("Unexpected result for nil:", v, err)
+	}
+}
@@ -1,13 +1,15 @@
 package req
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"strings"
 	"testing"
 )
 
 func TestVals_String(t *testing.T) {
-	v := Vals{{"name", Vals{{"k", "v"}}.JSON()},
+	v := Vals{{"name", json.RawMessage(Vals{{"k", "v"}}.JSON())},
 		{"name2", "val2"}}
 	s := fmt.Sprint(v)
 	if s != `["name":{"k":"v"} "name2":"val2"]` {
@@ -32,15 +34,21 @@ func TestVals_JSON11(t *testing.T) {
 }
 
 func TestVals_JSON2(t *testing.T) {
-	v := Vals{{"name", `["val1","val2"]`}}
+	v := Vals{{"name", json.RawMessage(`["val1","val2"]`)}}
 	s := v.JSON()
 	if s != `{"name":["val1","val2"]}` {
 		t.Fatal("Unexpected v.JSON:", s)
 	}
+	// strings are not embedded
+	v = Vals{{"name", `["val1","val2"]`}}
+	s = v.JSON()
+	if s != `{"name":"[\"val1\",\"val2\"]"}` {
+		t.Fatal("Unexpected v.JSON:", s)
+	}
 }
 
 func TestVals_JSON3(t *testing.T) {
-	v := Vals{{"name", Vals{{"nsub1", "vsub1"}}.JSON()}}
+	v := Vals{{"name", json.RawMessage(Vals{{"nsub1", "vsub1"}}.JSON())}}
 	s := v.JSON()
 	if s != `{"name":{"nsub1":"vsub1"}}` {
 		t.Fatal("Unexpected v.JSON:", s)
@@ -48,7 +56,7 @@ func TestVals_JSON3(t *testing.T) {
 }
 
 func TestVals_JSON4(t *testing.T) {
-	v := Vals{{"name", Vals{{"nsub1", "vsub1"}}.JSON()}}
+	v := Vals{{"name", Vals{{"nsub1", "vsub1"}}}}
 	s := v.JSON()
 	if s != `{"name":{"nsub1":"vsub1"}}` {
 		t.Fatal("Unexpected v.JSON:", s)
@@ -63,3 +71,59 @@ func TestVals_Extend(t *testing.T) {
 		t.Fatal("Unexpected v.String:", s)
 	}
 }
+
+func TestParseVals(t *testing.T) {
+	v, err := ParseVals("?b=1&a=x+y&b=2&c&d=%3D&&e=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.URLEncode() != "b=1&a=x+y&b=2&c=&d=%3D&e=" {
+		t.Fatal("Unexpected vals:", v)
+	}
+	v, err = ParseVals("a=1&b=%zz&c=3;d")
+	if err == nil {
+		t.Fatal("Expected err, but got nil")
+	}
+	if v.URLEncode() != "a=1" {
+		t.Fatal("Unexpected vals:", v)
+	}
+}
+
+func TestVals_URLValues(t *testing.T) {
+	u := url.Values{"b": {"1", "2"}, "a": {"3"}}
+	v := Vals{{"z", 0}}.FromURLValues(u)
+	if v.URLEncode() != "z=0&a=3&b=1&b=2" {
+		t.Fatal("Unexpected vals:", v)
+	}
+	u = v.ToURLValues()
+	if u.Encode() != "a=3&b=1&b=2&z=0" {
+		t.Fatal("Unexpected url.Values:", u)
+	}
+}
+
+func TestVals_Lookup(t *testing.T) {
+	v := Vals{{"a", 1}, {"b", 2}, {"a", 3}}
+	if !v.Has("a") || v.Has("c") || v.Index("b") != 1 || v.Index("c") != -1 {
+		t.Fatal("Unexpected lookup:", v)
+	}
+	if v.Get("a") != 1 || v.Get("c") != nil {
+		t.Fatal("Unexpected Get:", v)
+	}
+	if all := v.GetAll("a"); len(all) != 2 || all[1] != 3 {
+		t.Fatal("Unexpected GetAll:", all)
+	}
+
+	v.Set("a", 4)
+	if v.URLEncode() != "a=4&b=2" {
+		t.Fatal("Unexpected Set:", v)
+	}
+	v.Set("c", 5)
+	v.Add("b", 6)
+	if v.URLEncode() != "a=4&b=2&c=5&b=6" {
+		t.Fatal("Unexpected Set/Add:", v)
+	}
+	v.Del("b")
+	if v.URLEncode() != "a=4&c=5" {
+		t.Fatal("Unexpected Del:", v)
+	}
+}